@@ -0,0 +1,65 @@
+package humus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"github.com/dgraph-io/dgo/protos/api"
+	errors2 "github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//login logs in using the credentials in the config, if any.
+func (d *DB) login(ctx context.Context) error {
+	if d.c.User == "" {
+		if d.c.Password != "" {
+			return errors.New("login with password but without user")
+		}
+		return nil
+	}
+	d.loginMu.Lock()
+	defer d.loginMu.Unlock()
+	return d.loginLocked(ctx)
+}
+
+//loginLocked logs in while holding loginMu.
+func (d *DB) loginLocked(ctx context.Context) error {
+	if err := d.d.Login(ctx, d.c.User, d.c.Password); err != nil {
+		return errors2.Wrap(err, "login")
+	}
+	atomic.AddUint64(&d.logins, 1)
+	return nil
+}
+
+//tokenExpired returns whether the error is caused by an expired access token.
+func tokenExpired(err error) bool {
+	if err == nil {
+		return false
+	}
+	st, ok := status.FromError(errors2.Cause(err))
+	return ok && st.Code() == codes.Unauthenticated && strings.Contains(st.Message(), "Token is expired")
+}
+
+//withLogin runs f and, if it failed as the access token has expired, logs in and runs it again.
+//dgo refreshes the access token using its refresh token, so the error is only seen here once
+//the refresh token has expired as well. Calls failing concurrently only log in once.
+func (d *DB) withLogin(ctx context.Context, f func() (*api.Response, error)) (*api.Response, error) {
+	logins := atomic.LoadUint64(&d.logins)
+	resp, err := f()
+	if d.c.User == "" || !tokenExpired(err) {
+		return resp, err
+	}
+	d.loginMu.Lock()
+	//Another call logged in after this call started.
+	if atomic.LoadUint64(&d.logins) == logins {
+		if lerr := d.loginLocked(ctx); lerr != nil {
+			d.loginMu.Unlock()
+			return resp, err
+		}
+	}
+	d.loginMu.Unlock()
+	return f()
+}
@@ -0,0 +1,250 @@
+package humus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+//The variable holding the nodes of an aggregate query.
+const aggregateNodes = "aggNodes"
+
+//AggregateQuery is a query returning aggregate values over the nodes of a query,
+//such as the amount of nodes matching a function and filter or the sum of a predicate.
+//The query selecting the nodes is written as a var block and the aggregates in a
+//block over its nodes. The result is decoded into an AggregateResult.
+//
+//	a := humus.NewAggregate(humus.NewQuery(nil).Function(humus.Type).Values("Post"))
+//	a.Count("uid", "posts").Count(QuestionCommentsField, "comments")
+//	var res humus.AggregateResult
+//	err := db.Query(ctx, a, &res)
+//	posts := res.Int("posts")
+type AggregateQuery struct {
+	q      *GeneratedQuery
+	values []aggregateValue
+	query  string
+	err    error
+}
+
+//aggregateValue is a single aggregate written as alias : typ(val(v)).
+type aggregateValue struct {
+	typ   AggregateType
+	v     Variable
+	alias string
+}
+
+//NewAggregate creates an aggregate query over the nodes of q, which
+//should not be processed or used elsewhere. The fields of q are not returned.
+func NewAggregate(q *GeneratedQuery) *AggregateQuery {
+	a := &AggregateQuery{q: q}
+	if !q.single() {
+		a.err = errors.New("aggregate requires a single query")
+		return a
+	}
+	if q.fields == nil {
+		q.fields = NewList{}
+	}
+	q.Var(aggregateNodes)
+	q.aggregate = a
+	return a
+}
+
+//add adds an aggregate, failing on an invalid or duplicate alias.
+func (a *AggregateQuery) add(t AggregateType, v Variable, alias string) *AggregateQuery {
+	if a.err != nil {
+		return a
+	}
+	if !validAlias(alias) {
+		a.err = fmt.Errorf("invalid aggregate alias %s", alias)
+		return a
+	}
+	for _, val := range a.values {
+		if val.alias == alias {
+			a.err = fmt.Errorf("aggregate alias %s set twice", alias)
+			return a
+		}
+	}
+	a.values = append(a.values, aggregateValue{typ: t, v: v, alias: alias})
+	return a
+}
+
+//declare declares a value variable at the root of the nodes.
+func (a *AggregateQuery) declare(value string) Variable {
+	v := Variable("agg" + strconv.Itoa(len(a.values)))
+	a.q.At("", func(m Mod) {
+		m.Variable(string(v), value, false)
+	})
+	return v
+}
+
+//Count counts the nodes given a predicate of "uid", otherwise the total
+//amount of edges of the predicate p over the nodes.
+func (a *AggregateQuery) Count(p Predicate, alias string) *AggregateQuery {
+	if p == "uid" {
+		return a.add(Count, "", alias)
+	}
+	return a.add(Sum, a.declare("count("+string(p)+")"), alias)
+}
+
+//Sum sums the values of the predicate p over the nodes.
+func (a *AggregateQuery) Sum(p Predicate, alias string) *AggregateQuery {
+	return a.add(Sum, a.declare(string(p)), alias)
+}
+
+//Avg returns the average value of the predicate p over the nodes.
+func (a *AggregateQuery) Avg(p Predicate, alias string) *AggregateQuery {
+	return a.add(Avg, a.declare(string(p)), alias)
+}
+
+//Min returns the minimum value of the predicate p over the nodes.
+func (a *AggregateQuery) Min(p Predicate, alias string) *AggregateQuery {
+	return a.add(Min, a.declare(string(p)), alias)
+}
+
+//Max returns the maximum value of the predicate p over the nodes.
+func (a *AggregateQuery) Max(p Predicate, alias string) *AggregateQuery {
+	return a.add(Max, a.declare(string(p)), alias)
+}
+
+//Aggregate aggregates the value variable v, declared in the query of the nodes
+//using Mod.Value or Mod.Math. t is one of Sum, Avg, Min or Max.
+func (a *AggregateQuery) Aggregate(t AggregateType, v Variable, alias string) *AggregateQuery {
+	switch t {
+	case Sum, Avg, Min, Max:
+	default:
+		if a.err == nil {
+			a.err = fmt.Errorf("invalid aggregate %s on variable %s", t, v)
+		}
+		return a
+	}
+	if !validVariableName(string(v)) {
+		if a.err == nil {
+			a.err = fmt.Errorf("invalid variable %s", v)
+		}
+		return a
+	}
+	a.q.use(v)
+	return a.add(t, v, alias)
+}
+
+//Process compiles the query once, returning the same query on later calls.
+func (a *AggregateQuery) Process() (string, error) {
+	if a.err != nil {
+		return "", a.err
+	}
+	if a.query != "" {
+		return a.query, nil
+	}
+	str, err := a.q.Process()
+	if err != nil {
+		return "", err
+	}
+	a.query = str
+	return str, nil
+}
+
+//create writes the block of the aggregates over the nodes of the var block.
+func (a *AggregateQuery) create(sb *strings.Builder) error {
+	if len(a.values) == 0 {
+		return errors.New("aggregate query without aggregates")
+	}
+	sb.WriteString(a.q.blockName())
+	sb.WriteString("(func: uid(" + aggregateNodes + ")){")
+	for _, v := range a.values {
+		sb.WriteByte(' ')
+		sb.WriteString(v.alias)
+		sb.WriteString(" : ")
+		sb.WriteString(string(v.typ))
+		if v.typ == Count {
+			sb.WriteString("(uid)")
+			continue
+		}
+		sb.WriteString("(val(")
+		sb.WriteString(string(v.v))
+		sb.WriteString("))")
+	}
+	sb.WriteString(" }")
+	return nil
+}
+
+func (a *AggregateQuery) queryVars() map[string]string {
+	return a.q.queryVars()
+}
+
+func (a *AggregateQuery) predicates() map[string]Predicate {
+	return a.q.predicates()
+}
+
+func (a *AggregateQuery) names() []string {
+	return a.q.names()
+}
+
+//AggregateResult holds the values of an aggregate query keyed by alias.
+//Dgraph returns every aggregate in a separate object in the result list,
+//i.e. [{"posts":2},{"comments":1}], which are merged when decoding.
+type AggregateResult struct {
+	values map[string]jsoniter.RawMessage
+}
+
+//listResponse marks that the result list is decoded as a whole.
+func (a *AggregateResult) listResponse() {}
+
+//UnmarshalJSON merges the values of a single object or a list of them.
+func (a *AggregateResult) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || string(b) == "null" {
+		return nil
+	}
+	var list []map[string]jsoniter.RawMessage
+	if b[0] == '[' {
+		if err := json.Unmarshal(b, &list); err != nil {
+			return err
+		}
+	} else {
+		list = make([]map[string]jsoniter.RawMessage, 1)
+		if err := json.Unmarshal(b, &list[0]); err != nil {
+			return err
+		}
+	}
+	a.values = make(map[string]jsoniter.RawMessage)
+	for _, m := range list {
+		for k, v := range m {
+			a.values[k] = v
+		}
+	}
+	return nil
+}
+
+//Has returns whether the aggregate is in the result. Dgraph omits
+//aggregates over values when there are no values.
+func (a AggregateResult) Has(alias string) bool {
+	_, ok := a.values[alias]
+	return ok
+}
+
+//Float returns the aggregate as a float, or zero if missing or not a number.
+func (a AggregateResult) Float(alias string) float64 {
+	var f float64
+	if v, ok := a.values[alias]; ok {
+		_ = json.Unmarshal(v, &f)
+	}
+	return f
+}
+
+//Int returns the aggregate as an integer, i.e. a count.
+func (a AggregateResult) Int(alias string) int64 {
+	return int64(a.Float(alias))
+}
+
+//Decode decodes the aggregate into v, i.e. a *time.Time for the minimum of a date.
+func (a AggregateResult) Decode(alias string, v interface{}) error {
+	val, ok := a.values[alias]
+	if !ok {
+		return fmt.Errorf("aggregate %s not in result", alias)
+	}
+	return json.Unmarshal(val, v)
+}
@@ -0,0 +1,249 @@
+package humus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/dgo/protos/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//Balance defines how calls are spread over the alpha endpoints.
+type Balance int
+
+const (
+	//BalanceRoundRobin uses the healthy endpoints in turn.
+	BalanceRoundRobin Balance = iota
+	//BalanceLeastLoaded uses the healthy endpoint with the least calls in flight.
+	BalanceLeastLoaded
+)
+
+//Default interval between health checks of the endpoints.
+const defaultHealthInterval = 5 * time.Second
+
+//errNoEndpoint is returned when there are no endpoints to call.
+var errNoEndpoint = errors.New("no dgraph endpoint available")
+
+//EndpointStatus is the state of a single alpha endpoint.
+type EndpointStatus struct {
+	//The address of the alpha.
+	Addr string
+	//Whether the last health check or call succeeded.
+	Healthy bool
+	//The amount of calls in flight.
+	Active int64
+}
+
+//endpoint is a single alpha along with its health and load. Every endpoint is given
+//to dgo as a client, which routes its calls through the balancer so the strategy and
+//failover apply regardless of which client dgo picks.
+type endpoint struct {
+	addr    string
+	conn    *grpc.ClientConn
+	client  api.DgraphClient
+	b       *balancer
+	healthy int32
+	active  int64
+}
+
+func (e *endpoint) isHealthy() bool {
+	return atomic.LoadInt32(&e.healthy) == 1
+}
+
+func (e *endpoint) setHealthy(healthy bool) {
+	var v int32
+	if healthy {
+		v = 1
+	}
+	atomic.StoreInt32(&e.healthy, v)
+}
+
+//balancer spreads calls over the endpoints. An endpoint is marked unhealthy when
+//a call fails as unavailable and healthy again once its health check succeeds.
+//Only calls which are safe to repeat are retried on the next endpoint.
+type balancer struct {
+	endpoints []*endpoint
+	strategy  Balance
+	next      uint64
+	stop      chan struct{}
+	once      sync.Once
+}
+
+func newBalancer(endpoints []*endpoint, strategy Balance, interval time.Duration) *balancer {
+	b := &balancer{
+		endpoints: endpoints,
+		strategy:  strategy,
+		stop:      make(chan struct{}),
+	}
+	for _, v := range endpoints {
+		v.b = b
+		v.setHealthy(true)
+	}
+	if interval <= 0 {
+		interval = defaultHealthInterval
+	}
+	go b.checkHealth(interval)
+	return b
+}
+
+//checkHealth checks the version of every endpoint each interval until closed.
+func (b *balancer) checkHealth(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+		for _, v := range b.endpoints {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			_, err := v.client.CheckVersion(ctx, &api.Check{})
+			cancel()
+			v.setHealthy(err == nil)
+		}
+	}
+}
+
+//pick returns the endpoint to call, skipping those already tried. Healthy endpoints
+//are preferred but unhealthy ones are used if there are no healthy endpoints left.
+func (b *balancer) pick(tried []*endpoint) *endpoint {
+	var best *endpoint
+	n := len(b.endpoints)
+	start := int(atomic.AddUint64(&b.next, 1) % uint64(n))
+loop:
+	for i := 0; i < n; i++ {
+		e := b.endpoints[(start+i)%n]
+		for _, v := range tried {
+			if v == e {
+				continue loop
+			}
+		}
+		switch {
+		case best == nil:
+			best = e
+		case e.isHealthy() != best.isHealthy():
+			if e.isHealthy() {
+				best = e
+			}
+		case b.strategy == BalanceLeastLoaded && atomic.LoadInt64(&e.active) < atomic.LoadInt64(&best.active):
+			best = e
+		}
+		if best.isHealthy() && b.strategy == BalanceRoundRobin {
+			break
+		}
+	}
+	return best
+}
+
+//call runs f on an endpoint. When unavailable the endpoint is marked unhealthy and,
+//if retry is set, the call fails over to the next endpoint.
+func (b *balancer) call(ctx context.Context, retry bool, f func(c api.DgraphClient) error) error {
+	var tried []*endpoint
+	var err = errNoEndpoint
+	for len(tried) < len(b.endpoints) {
+		e := b.pick(tried)
+		tried = append(tried, e)
+		atomic.AddInt64(&e.active, 1)
+		err = f(e.client)
+		atomic.AddInt64(&e.active, -1)
+		if status.Code(err) != codes.Unavailable {
+			return err
+		}
+		e.setHealthy(false)
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+//clients returns the endpoints as clients for dgo.
+func (b *balancer) clients() []api.DgraphClient {
+	var ret = make([]api.DgraphClient, len(b.endpoints))
+	for k, v := range b.endpoints {
+		ret[k] = v
+	}
+	return ret
+}
+
+//readOnly returns whether the request only reads and therefore can be repeated.
+func readOnly(req *api.Request) bool {
+	return req.ReadOnly && len(req.Mutations) == 0 && !req.CommitNow
+}
+
+//status returns the state of the endpoints.
+func (b *balancer) status() []EndpointStatus {
+	var ret = make([]EndpointStatus, len(b.endpoints))
+	for k, v := range b.endpoints {
+		ret[k] = EndpointStatus{
+			Addr:    v.addr,
+			Healthy: v.isHealthy(),
+			Active:  atomic.LoadInt64(&v.active),
+		}
+	}
+	return ret
+}
+
+//close stops the health checks and closes the connections.
+func (b *balancer) close() {
+	b.once.Do(func() {
+		close(b.stop)
+		for _, v := range b.endpoints {
+			_ = v.conn.Close()
+		}
+	})
+}
+
+func (e *endpoint) Login(ctx context.Context, in *api.LoginRequest, opts ...grpc.CallOption) (resp *api.Response, err error) {
+	err = e.b.call(ctx, true, func(c api.DgraphClient) error {
+		resp, err = c.Login(ctx, in, opts...)
+		return err
+	})
+	return
+}
+
+func (e *endpoint) Query(ctx context.Context, in *api.Request, opts ...grpc.CallOption) (resp *api.Response, err error) {
+	err = e.b.call(ctx, readOnly(in), func(c api.DgraphClient) error {
+		resp, err = c.Query(ctx, in, opts...)
+		return err
+	})
+	return
+}
+
+func (e *endpoint) Alter(ctx context.Context, in *api.Operation, opts ...grpc.CallOption) (resp *api.Payload, err error) {
+	err = e.b.call(ctx, false, func(c api.DgraphClient) error {
+		resp, err = c.Alter(ctx, in, opts...)
+		return err
+	})
+	return
+}
+
+func (e *endpoint) CommitOrAbort(ctx context.Context, in *api.TxnContext, opts ...grpc.CallOption) (resp *api.TxnContext, err error) {
+	err = e.b.call(ctx, false, func(c api.DgraphClient) error {
+		resp, err = c.CommitOrAbort(ctx, in, opts...)
+		return err
+	})
+	return
+}
+
+func (e *endpoint) CheckVersion(ctx context.Context, in *api.Check, opts ...grpc.CallOption) (resp *api.Version, err error) {
+	err = e.b.call(ctx, true, func(c api.DgraphClient) error {
+		resp, err = c.CheckVersion(ctx, in, opts...)
+		return err
+	})
+	return
+}
+
+//Endpoints returns the state of the alpha endpoints.
+func (d *DB) Endpoints() []EndpointStatus {
+	if d.balancer == nil {
+		return nil
+	}
+	return d.balancer.status()
+}
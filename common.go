@@ -1,5 +1,7 @@
 package humus
 
+import "context"
+
 //The common node type that is inherited. This differs from the DNode which is an interface while
 //node is an embedded struct containing basic dgraph properties.
 type Node struct {
@@ -72,3 +74,32 @@ func GetByPredicate(pred Predicate, fields Fields, values ...interface{}) *Gener
 	q.predValues(pred, values)
 	return q
 }
+
+//countAlias is the alias of the count in CountQuery and ExistsQuery.
+const countAlias = "count"
+
+//CountQuery is shorthand for generating a query counting the nodes
+//matching the function, i.e. CountQuery(Type, "Event"). Nodes are not decoded.
+func CountQuery(ft FunctionType, values ...interface{}) *AggregateQuery {
+	return NewAggregate(NewQuery(nil).Function(ft).Values(values...)).Count("uid", countAlias)
+}
+
+//ExistsQuery is shorthand for generating a query checking whether a node
+//with the predicate value exists. At most a single node is counted.
+func ExistsQuery(pred Predicate, value interface{}) *AggregateQuery {
+	q := NewQuery(nil).Function(Equals)
+	q.predValues(pred, []interface{}{value})
+	q.At("", func(m Mod) {
+		m.Paginate(CountFirst, 1)
+	})
+	return NewAggregate(q).Count("uid", countAlias)
+}
+
+//queryCount runs the aggregate query using the querier and returns the count.
+func queryCount(ctx context.Context, q Querier, agg *AggregateQuery) (int, error) {
+	var res AggregateResult
+	if err := q.Query(ctx, agg, &res); err != nil {
+		return 0, err
+	}
+	return int(res.Int(countAlias)), nil
+}
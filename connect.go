@@ -0,0 +1,145 @@
+package humus
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+
+	errors2 "github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+)
+
+//probeQuery is run by Connect to verify the connection.
+const probeQuery = "{probe(func: uid(0x1)){uid}}"
+
+//Connect creates a database object as Init, using the schema in the config, but returns
+//errors instead of panicking. The config is validated and the connection is verified
+//by running a probe query, which fails if no alpha is reachable before ctx is done.
+func Connect(ctx context.Context, conf *Config) (*DB, error) {
+	if conf == nil {
+		return nil, errors.New("connect without config")
+	}
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	db, err := connect(ctx, conf, conf.Schema)
+	if err != nil {
+		return nil, err
+	}
+	txn := db.d.NewReadOnlyTxn()
+	_, err = txn.Query(ctx, probeQuery)
+	_ = txn.Discard(context.Background())
+	if err != nil {
+		db.Cleanup()
+		return nil, errors2.Wrap(err, "probe query")
+	}
+	return db, nil
+}
+
+//validate ensures the endpoints and options are valid.
+func (c *Config) validate() error {
+	if c.Schema == nil {
+		return errors.New("config without schema")
+	}
+	for _, v := range c.endpoints() {
+		host, port, err := net.SplitHostPort(v)
+		if err != nil {
+			return errors2.Wrapf(err, "invalid endpoint %s", v)
+		}
+		if host == "" {
+			return fmt.Errorf("invalid endpoint %s without host", v)
+		}
+		if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+			return fmt.Errorf("invalid endpoint %s port", v)
+		}
+	}
+	if c.Balance != BalanceRoundRobin && c.Balance != BalanceLeastLoaded {
+		return fmt.Errorf("invalid balance %d", c.Balance)
+	}
+	if c.HealthInterval < 0 || c.KeepaliveTime < 0 || c.KeepaliveTimeout < 0 {
+		return errors.New("negative duration in config")
+	}
+	if c.MaxRecvMsgSize < 0 || c.MaxSendMsgSize < 0 {
+		return errors.New("negative message size in config")
+	}
+	if !c.Tls && (c.RootCA != "" || c.NodeCRT != "" || c.NodeKey != "" || len(c.RootCAPEM) > 0 ||
+		len(c.CertPEM) > 0 || len(c.KeyPEM) > 0 || c.ServerName != "") {
+		return errors.New("tls options set without Tls")
+	}
+	if (c.NodeCRT == "") != (c.NodeKey == "") || (len(c.CertPEM) == 0) != (len(c.KeyPEM) == 0) {
+		return errors.New("client certificate requires both certificate and key")
+	}
+	return nil
+}
+
+//tlsConfig returns the TLS configuration from the files or PEM in the config.
+//Without a root CA the certificates of the host are used.
+func (c *Config) tlsConfig() (*tls.Config, error) {
+	var conf = &tls.Config{ServerName: c.ServerName}
+	ca := c.RootCAPEM
+	if c.RootCA != "" {
+		var err error
+		ca, err = ioutil.ReadFile(c.RootCA)
+		if err != nil {
+			return nil, errors2.Wrap(err, "root CA")
+		}
+	}
+	if len(ca) > 0 {
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("root CA without valid certificates")
+		}
+	}
+	var cert tls.Certificate
+	var err error
+	switch {
+	case c.NodeCRT != "":
+		cert, err = tls.LoadX509KeyPair(c.NodeCRT, c.NodeKey)
+	case len(c.CertPEM) > 0:
+		cert, err = tls.X509KeyPair(c.CertPEM, c.KeyPEM)
+	default:
+		return conf, nil
+	}
+	if err != nil {
+		return nil, errors2.Wrap(err, "client certificate")
+	}
+	conf.Certificates = append(conf.Certificates, cert)
+	return conf, nil
+}
+
+//dialOptions returns the options to dial the alphas with.
+func (c *Config) dialOptions() ([]grpc.DialOption, error) {
+	var call = []grpc.CallOption{grpc.UseCompressor(gzip.Name)}
+	if c.MaxRecvMsgSize > 0 {
+		call = append(call, grpc.MaxCallRecvMsgSize(c.MaxRecvMsgSize))
+	}
+	if c.MaxSendMsgSize > 0 {
+		call = append(call, grpc.MaxCallSendMsgSize(c.MaxSendMsgSize))
+	}
+	var opts = []grpc.DialOption{grpc.WithDefaultCallOptions(call...)}
+	if c.Tls {
+		conf, err := c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(conf)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	if c.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                c.KeepaliveTime,
+			Timeout:             c.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}))
+	}
+	return append(opts, c.DialOptions...), nil
+}
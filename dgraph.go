@@ -2,12 +2,8 @@ package humus
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"reflect"
 	"strconv"
 	"sync"
@@ -16,9 +12,8 @@ import (
 	"github.com/dgraph-io/dgo"
 	"github.com/dgraph-io/dgo/protos/api"
 	"github.com/gammazero/workerpool"
+	errors2 "github.com/pkg/errors"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/encoding/gzip"
 )
 
 //Deprecated information but useful.
@@ -41,8 +36,64 @@ type Config struct {
 	NodeCRT string
 	//NodeKey is the path for the NodeKey.
 	NodeKey string
-	//Log queries in stdout along their result.
+	//LogQueries logs all queries and mutations to the standard logger.
+	//It is the same as setting Logger to NewStdLogger(nil, LevelDebug).
 	LogQueries bool
+	//Logger logs every query, mutation and upsert with its kind, names, duration,
+	//amount of variables and result size.
+	Logger Logger
+	//SlowQuery is the duration from which requests are logged at warn level along with
+	//the query, variables and mutation json. Bodies are never logged if zero.
+	SlowQuery time.Duration
+	//Redact are the predicates whose values are masked in logged bodies.
+	//Predicates with MetaIgnore in the schema, i.e. passwords, are always masked.
+	Redact []Predicate
+	//Tracer opens a span for every query, mutation, upsert, commit and discard in a
+	//transaction. Nothing is traced if nil.
+	Tracer Tracer
+	//Endpoints are the addresses of the alphas, i.e. localhost:9080.
+	//If empty the alpha at IP and Port is used.
+	Endpoints []string
+	//Balance is how calls are spread over the endpoints.
+	Balance Balance
+	//HealthInterval is the interval between health checks of the endpoints.
+	//Defaults to five seconds.
+	HealthInterval time.Duration
+	//Schema is the schema of the database, i.e. GetGlobalFields of the generated package.
+	//It is used by Connect.
+	Schema SchemaList
+	//RootCAPEM, CertPEM and KeyPEM are the root CA and client certificate in PEM, used
+	//instead of the files. A client certificate enables mutual TLS.
+	RootCAPEM []byte
+	CertPEM   []byte
+	KeyPEM    []byte
+	//ServerName overrides the name used to verify the certificates of the alphas.
+	ServerName string
+	//KeepaliveTime is the interval of keepalive pings on idle connections and
+	//KeepaliveTimeout how long to wait for a ping before closing the connection.
+	//Keepalive is disabled if KeepaliveTime is zero.
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+	//MaxRecvMsgSize and MaxSendMsgSize limit the size of messages in bytes.
+	//Zero uses the grpc defaults.
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+	//DialOptions are appended to the options used to dial the alphas.
+	DialOptions []grpc.DialOption
+	//User and Password are the credentials when ACLs are enabled. The DB logs in on
+	//connect and again when the access token has expired.
+	//Logging in to a namespace is not supported. The login request of this dgo client has
+	//no namespace, so the DB always logs in to the default namespace.
+	User     string
+	Password string
+}
+
+//endpoints returns the addresses of the alphas.
+func (c *Config) endpoints() []string {
+	if len(c.Endpoints) > 0 {
+		return c.Endpoints
+	}
+	return []string{c.IP + ":" + strconv.Itoa(c.Port)}
 }
 
 //Number of workers for the asynchronous DB pool.
@@ -54,6 +105,18 @@ const workers = 5
 type DB struct {
 	//The api to graph.
 	d *dgo.Dgraph
+	//The client spreading calls over the alphas.
+	balancer *balancer
+	//Serializes logins when access tokens expire.
+	loginMu sync.Mutex
+	//The amount of logins, used to skip logins for calls which failed before a login.
+	logins uint64
+	//The middleware wrapping every call in a Txn and the chain built from it.
+	middlewareMu sync.RWMutex
+	middleware   []Middleware
+	handler      Handler
+	//The tracer of the operations in a Txn.
+	tracer Tracer
 	//Config.
 	c *Config
 	//Schema list.
@@ -94,13 +157,18 @@ type DNode interface {
 type Querier interface {
 	//Query queries the database with a variable amount of interfaces to deserialize into.
 	//That is, if you are performing two queries q and q1 you are expected to supply two values.
+	//See QueryMap on DB and Txn to deserialize into a map keyed by the query names.
 	Query(context.Context, Query, ...interface{}) error
 	//mutate mutates the query and returns the response.
-	Mutate(context.Context, Query) (*api.Response, error)
+	Mutate(context.Context, Mutate) (*api.Response, error)
 	//Discard the transaction. This is done automatically in DB but not in Txn.
 	Discard(context.Context) error
 	//Commit is the same as above except it commits the transaction.
 	Commit(context.Context) error
+	//Exists returns whether a node with the predicate value exists.
+	Exists(context.Context, Predicate, interface{}) (bool, error)
+	//Count returns the amount of nodes matching the function.
+	Count(context.Context, FunctionType, ...interface{}) (int, error)
 }
 
 //AsyncQuerier is an interface representing a querier that can also
@@ -108,9 +176,15 @@ type Querier interface {
 type AsyncQuerier interface {
 	Querier
 	QueryAsync(context.Context, Query, ...interface{}) chan Result
-	MutateAsync(context.Context, Query) chan Result
+	MutateAsync(context.Context, Mutate) chan Result
 }
 
+//Ensure the database and transactions are queriers.
+var (
+	_ Querier      = (*DB)(nil)
+	_ AsyncQuerier = (*Txn)(nil)
+)
+
 //Saver allows you to implement a custom save method.
 type Saver interface {
 	Save() (DNode, error)
@@ -313,12 +387,25 @@ func (d *DB) Select(vals ...Predicate) Fields {
 func (d *DB) Query(ctx context.Context, q Query, objs ...interface{}) error {
 	txn := d.NewTxn(true)
 	defer txn.Discard(context.Background())
-	err := txn.Query(ctx, q, objs...)
-	//TODO: Can we do this for readonly?
-	if err != nil {
-		fmt.Println(err)
-	}
-	return err
+	return txn.Query(ctx, q, objs...)
+}
+
+//QueryMap queries outside a Txn context, see Txn.QueryMap.
+func (d *DB) QueryMap(ctx context.Context, q Query, m interface{}) error {
+	txn := d.NewTxn(true)
+	defer txn.Discard(context.Background())
+	return txn.QueryMap(ctx, q, m)
+}
+
+//Exists returns whether a node with the predicate value exists, see ExistsQuery.
+func (d *DB) Exists(ctx context.Context, pred Predicate, value interface{}) (bool, error) {
+	n, err := queryCount(ctx, d, ExistsQuery(pred, value))
+	return n > 0, err
+}
+
+//Count returns the amount of nodes matching the function, see CountQuery.
+func (d *DB) Count(ctx context.Context, ft FunctionType, values ...interface{}) (int, error) {
+	return queryCount(ctx, d, CountQuery(ft, values...))
 }
 
 //QueryAsync runs the query and returns a channel with the result.
@@ -379,6 +466,7 @@ func (d *DB) Mutate(ctx context.Context, m Mutate) (*api.Response, error) {
 //Cleanup should be deferred at the main function.
 func (d *DB) Cleanup() {
 	d.pool.StopWait()
+	d.balancer.close()
 }
 
 //Alter runs the command given by op to the dgraph instance.
@@ -413,50 +501,62 @@ type Mutate interface {
 //to the specified destination with or without TLS.
 //It also sets the database schema using a pregenerated schema
 //from humus/gen. Any query or mutation to the database goes through this object.
+//It panics on an invalid config, see Connect for returning errors.
 func Init(conf *Config, sch SchemaList) *DB {
-	if conf.Port < 1000 {
+	if len(conf.Endpoints) == 0 && conf.Port < 1000 {
 		panic("graphinit: invalid dgraph port number")
 	}
-	db := connect(conf, sch)
+	db, err := connect(context.Background(), conf, sch)
+	if err != nil {
+		panic(err)
+	}
 	return db
 }
 
-func connect(conf *Config, sch SchemaList) *DB {
-	//TODO: allow multiple dgraph clusters.
-	var conn *grpc.ClientConn
-	var err error
-	if conf.Tls {
-		//TODO: Review this code as it has not been tried in some time.
-		rootCAs := x509.NewCertPool()
-		cCerts, err := tls.LoadX509KeyPair(conf.NodeCRT, conf.NodeKey)
-		certs, err := ioutil.ReadFile(conf.RootCA)
-		rootCAs.AppendCertsFromPEM(certs)
-		tlsConf := &tls.Config{}
-		tlsConf.RootCAs = rootCAs
-		tlsConf.Certificates = append(tlsConf.Certificates, cCerts)
-		c := credentials.NewTLS(tlsConf)
-		conn, err = grpc.Dial(conf.IP+":"+strconv.Itoa(conf.Port), grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)), grpc.WithTransportCredentials(c))
-		if err != nil {
-			panic(err)
-		}
-	} else {
-		conn, err = grpc.Dial(conf.IP+":"+strconv.Itoa(conf.Port), grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)), grpc.WithInsecure())
+func connect(ctx context.Context, conf *Config, sch SchemaList) (*DB, error) {
+	opts, err := conf.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+	var endpoints []*endpoint
+	for _, addr := range conf.endpoints() {
+		conn, err := grpc.Dial(addr, opts...)
 		if err != nil {
-			panic(err)
+			for _, v := range endpoints {
+				_ = v.conn.Close()
+			}
+			return nil, errors2.Wrapf(err, "dial %s", addr)
 		}
-	}
-	//TODO: For multiple DGraph servers append multiple connections here.
-	var c = dgo.NewDgraphClient(api.NewDgraphClient(conn))
+		endpoints = append(endpoints, &endpoint{
+			addr:   addr,
+			conn:   conn,
+			client: api.NewDgraphClient(conn),
+		})
+	}
+	//All alphas are used through the balancer which fails over between them.
+	b := newBalancer(endpoints, conf.Balance, conf.HealthInterval)
+	var c = dgo.NewDgraphClient(b.clients()...)
 	db := &DB{
-		d: c,
+		d:        c,
+		balancer: b,
 		//gplPoint: conf.IP + ":" + strconv.Itoa(conf.Port) + "/graphql",
 		c:      conf,
 		schema: sch,
+		tracer: conf.Tracer,
 	}
-	//Run an empty query to ensure connection.
 	db.pool = workerpool.New(workers)
-	//_ = db.Query(context.Background(), NewStaticQuery(""), nil)
-	return db
+	logger := conf.Logger
+	if logger == nil && conf.LogQueries {
+		logger = NewStdLogger(nil, LevelDebug)
+	}
+	if logger != nil {
+		db.Use(logMiddleware(logger, conf.SlowQuery, newRedactor(sch, conf.Redact)))
+	}
+	if err := db.login(ctx); err != nil {
+		db.Cleanup()
+		return nil, err
+	}
+	return db, nil
 }
 
 //Txn is an abstraction over a dgraph transaction.
@@ -474,21 +574,29 @@ type Txn struct {
 	db *DB
 	//To immediately commit mutations inside this txn.
 	commitNow bool
+	//The start timestamp from dgraph, used in spans.
+	startTs uint64
 }
 
 //Commit commits the transaction to the database.
-func (t *Txn) Commit(ctx context.Context) error {
+func (t *Txn) Commit(ctx context.Context) (err error) {
+	ctx, span := t.startSpan(ctx, SpanCommit)
+	defer func() { span.finish(nil, err) }()
 	return t.txn.Commit(ctx)
 }
 
 //Discard discards the given transaction. Any further queries
 //on this txn results in an error.
-func (t *Txn) Discard(ctx context.Context) error {
+func (t *Txn) Discard(ctx context.Context) (err error) {
+	ctx, span := t.startSpan(ctx, SpanDiscard)
+	defer func() { span.finish(nil, err) }()
 	return t.txn.Discard(ctx)
 }
 
 //Perform a single mutation.
-func (t *Txn) mutate(ctx context.Context, q Mutate) (*api.Response, error) {
+func (t *Txn) mutate(ctx context.Context, q Mutate) (a *api.Response, err error) {
+	ctx, span := t.startSpan(ctx, SpanMutate)
+	defer func() { span.finish(a, err) }()
 	//Add a single mutation to the query list.
 	byt, err := q.mutate()
 	if err != nil {
@@ -498,17 +606,17 @@ func (t *Txn) mutate(ctx context.Context, q Mutate) (*api.Response, error) {
 	typ := q.Type()
 	if typ == MutateSet {
 		m.SetJson = byt
-		if t.db.c.LogQueries {
-			fmt.Println(string(m.SetJson))
-		}
 	} else if typ == MutateDelete {
 		m.DeleteJson = byt
-		if t.db.c.LogQueries {
-			fmt.Println(string(m.DeleteJson))
-		}
 	}
 	m.CommitNow = t.commitNow
-	a, err := t.txn.Mutate(ctx, &m)
+	var req = Request{Kind: RequestMutate, Mutations: []*api.Mutation{&m}}
+	span.request(&req)
+	a, err = t.db.run(ctx, &req, func(ctx context.Context, req *Request) (*api.Response, error) {
+		return t.db.withLogin(ctx, func() (*api.Response, error) {
+			return t.txn.Mutate(ctx, req.Mutations[0])
+		})
+	})
 	if err != nil {
 		//	t.db.logError(context.Background(), err)
 	}
@@ -521,7 +629,9 @@ func (t *Txn) mutate(ctx context.Context, q Mutate) (*api.Response, error) {
 //Upsert follows the new 1.1 api and performs an upsert.
 //q is a nameless query. For now it is recommended to use a static query for all upserts.
 //Cond is a condition of the form if (eq(len(a), 0) and so on. mutations is a list of mutations to perform.
-func (t *Txn) Upsert(ctx context.Context, q Query, mutations ...Mutate) (*api.Response, error) {
+func (t *Txn) Upsert(ctx context.Context, q Query, mutations ...Mutate) (resp *api.Response, err error) {
+	ctx, span := t.startSpan(ctx, SpanUpsert)
+	defer func() { span.finish(resp, err) }()
 	if t.txn == nil {
 		return nil, Error(errTransaction)
 	}
@@ -544,12 +654,25 @@ func (t *Txn) Upsert(ctx context.Context, q Query, mutations ...Mutate) (*api.Re
 		}
 		v.Cond = mutations[k].Cond()
 	}
-	var req = api.Request{
-		Query:     b,
-		Vars:      q.queryVars(),
-		Mutations: muts,
-	}
-	resp, err := t.txn.Do(ctx, &req)
+	var req = Request{
+		Kind:       RequestUpsert,
+		Names:      q.names(),
+		Query:      b,
+		Vars:       q.queryVars(),
+		Predicates: predicates(q),
+		Mutations:  muts,
+	}
+	span.request(&req)
+	resp, err = t.db.run(ctx, &req, func(ctx context.Context, req *Request) (*api.Response, error) {
+		var do = api.Request{
+			Query:     req.Query,
+			Vars:      req.Vars,
+			Mutations: req.Mutations,
+		}
+		return t.db.withLogin(ctx, func() (*api.Response, error) {
+			return t.txn.Do(ctx, &do)
+		})
+	})
 	if err != nil {
 		return nil, Error(err)
 	}
@@ -557,39 +680,50 @@ func (t *Txn) Upsert(ctx context.Context, q Query, mutations ...Mutate) (*api.Re
 	return resp, nil
 }
 
-func (t *Txn) query(ctx context.Context, q Query, objs []interface{}) error {
+//query runs the query and decodes the result into objs, or into the map m if valid.
+//Errors are wrapped by the caller.
+func (t *Txn) query(ctx context.Context, q Query, objs []interface{}, m reflect.Value) (err error) {
+	ctx, span := t.startSpan(ctx, SpanQuery)
+	var resp *api.Response
+	defer func() { span.finish(resp, err) }()
 	str, err := q.Process()
 	if err != nil {
 		return err
 	}
 	names := q.names()
-	if len(names) != len(objs) {
-		return Error(errors.New("mismatched length between query amount and input interfaces"))
-	}
-	if t.db.c.LogQueries {
-		log.Printf("Query input: %s \n", str)
-	}
-	resp, err := t.txn.QueryWithVars(ctx, str, q.queryVars())
+	isMap := m.IsValid()
+	if !isMap && len(names) != len(objs) {
+		return errors.New("mismatched length between query amount and input interfaces")
+	}
+	var req = Request{Kind: RequestQuery, Names: names, Query: str, Vars: q.queryVars(), Predicates: predicates(q)}
+	span.request(&req)
+	resp, err = t.db.run(ctx, &req, func(ctx context.Context, req *Request) (*api.Response, error) {
+		return t.db.withLogin(ctx, func() (*api.Response, error) {
+			return t.txn.QueryWithVars(ctx, req.Query, req.Vars)
+		})
+	})
 	if err == dgo.ErrAborted && t.db.interruptFunc != nil {
 		t.db.interruptFunc(q)
 	}
 	if err != nil {
 		//t.db.logError(context.Background(), err)
-		return Error(err)
+		return err
 	}
-	if t.db.c.LogQueries {
-		log.Printf("Query output: %s", string(resp.Json))
+	res, err := splitPaths(resp.Json, shortestBlocks(q))
+	if err != nil {
+		return err
 	}
 	//This deserializes using reflect.
-	err = handleResponse(resp.Json, objs, names)
+	if isMap {
+		err = handleMapResponse(res, m)
+	} else {
+		err = handleResponse(res, objs, names)
+	}
 	//TODO: Ignore this error for now. Some oddity in dgraph when time is defaulted.
 	if _, ok := err.(*time.ParseError); ok {
 		return nil
 	}
-	if err != nil {
-		return Error(err)
-	}
-	return nil
+	return err
 }
 
 //Result represents a result from an asynchronous operation.
@@ -617,7 +751,31 @@ func (t *Txn) Query(ctx context.Context, q Query, objs ...interface{}) error {
 	t.Lock()
 	defer t.Unlock()
 	t.Queries = append(t.Queries, q)
-	return Error(t.query(ctx, q, objs))
+	return Error(t.query(ctx, q, objs, reflect.Value{}))
+}
+
+//QueryMap executes the query and deserializes the result into the map m keyed by
+//the query names, such as map[string]json.RawMessage or a pointer to a map.
+func (t *Txn) QueryMap(ctx context.Context, q Query, m interface{}) error {
+	val, ok := mapResponse(m)
+	if !ok {
+		return Error(errors.New("query map requires a map keyed by strings"))
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.Queries = append(t.Queries, q)
+	return Error(t.query(ctx, q, nil, val))
+}
+
+//Exists returns whether a node with the predicate value exists, see ExistsQuery.
+func (t *Txn) Exists(ctx context.Context, pred Predicate, value interface{}) (bool, error) {
+	n, err := queryCount(ctx, t, ExistsQuery(pred, value))
+	return n > 0, err
+}
+
+//Count returns the amount of nodes matching the function, see CountQuery.
+func (t *Txn) Count(ctx context.Context, ft FunctionType, values ...interface{}) (int, error) {
+	return queryCount(ctx, t, CountQuery(ft, values...))
 }
 
 //Mutate runs a single mutation inside this transaction object.
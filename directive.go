@@ -1,5 +1,13 @@
 package humus
 
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
 //Directive contains all possible query directives for dgraph.
 //These are applied at the root of the query.
 type Directive string
@@ -9,3 +17,93 @@ const (
 	Normalize    Directive = "normalize"
 	IgnoreReflex Directive = "ignorereflex"
 )
+
+//recurse represents the @recurse directive along with its arguments.
+//It is applied at the root using GeneratedQuery.Recurse.
+type recurse struct {
+	active bool
+	depth  int
+	loop   bool
+}
+
+func (r recurse) create(sb *strings.Builder) error {
+	if r.loop && r.depth <= 0 {
+		return errors.New("recurse with loop requires a depth")
+	}
+	sb.WriteString("@recurse(")
+	if r.depth > 0 {
+		sb.WriteString("depth: ")
+		sb.WriteString(strconv.Itoa(r.depth))
+		sb.WriteString(", ")
+	}
+	sb.WriteString("loop: ")
+	sb.WriteString(strconv.FormatBool(r.loop))
+	sb.WriteByte(')')
+	return nil
+}
+
+//Normalize sets @normalize on the query, returning flattened results which are decoded
+//into a flat struct such as v, i.e. a []Row for a Row. The leaf predicates in the query
+//matching the predicate tags of v are aliased using the predicate, as only aliased
+//predicates are returned. Fields already aliased using Field.As are kept as is.
+//The uid and dgraph.type predicates are not returned in a normalize query.
+func (q *GeneratedQuery) Normalize(v interface{}) *GeneratedQuery {
+	q.normalize = reflect.TypeOf(v)
+	return q.Directive(Normalize)
+}
+
+//normalizePredicates returns the predicates from the predicate tags of the flat struct.
+func normalizePredicates(typ reflect.Type) (map[Predicate]struct{}, error) {
+	for typ != nil && (typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice) {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, errors.New("normalize requires a struct")
+	}
+	var preds = make(map[Predicate]struct{})
+	for i := 0; i < typ.NumField(); i++ {
+		tag := typ.Field(i).Tag.Get("predicate")
+		if i := strings.IndexByte(tag, ','); i != -1 {
+			tag = tag[:i]
+		}
+		if tag == "" || tag == "-" {
+			continue
+		}
+		//The uid is never aliased and as such not returned.
+		if _, ok := builtinPredicates[Predicate(tag)]; ok {
+			continue
+		}
+		if !validAlias(tag) {
+			return nil, fmt.Errorf("invalid normalize predicate %s", tag)
+		}
+		preds[Predicate(tag)] = struct{}{}
+	}
+	if len(preds) == 0 {
+		return nil, errors.New("normalize struct without predicates")
+	}
+	return preds, nil
+}
+
+//checkNormalize ensures all predicates are selected in the fields.
+func checkNormalize(fields Fields, preds map[Predicate]struct{}) error {
+	var found = make(map[Predicate]struct{}, len(preds))
+	var walk func(fields Fields)
+	walk = func(fields Fields) {
+		if fields == nil {
+			return
+		}
+		for _, v := range fields.Get() {
+			if _, ok := preds[v.Name]; ok && !v.Meta.Object() {
+				found[v.Name] = struct{}{}
+			}
+			walk(v.Fields)
+		}
+	}
+	walk(fields)
+	for k := range preds {
+		if _, ok := found[k]; !ok {
+			return fmt.Errorf("normalize predicate %s not in query", k)
+		}
+	}
+	return nil
+}
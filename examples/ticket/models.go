@@ -25,7 +25,7 @@ type Event struct {
 	Premium     int     `json:"premium" predicate:"~Event.attending|premium,omitempty"`
 }
 
-var EventFields humus.Fields = humus.FieldList([]humus.Field{MakeField("Event.name", 0), MakeField("Event.attending", 0|humus.MetaObject|humus.MetaList|humus.MetaReverse), MakeField("Event.prices", 0|humus.MetaList), MakeField("Event.description", 0), MakeField("~Event.attending|premium", 0|humus.MetaFacet)})
+var EventFields humus.Fields = humus.FieldList([]humus.Field{MakeField("Event.name", 0|humus.MetaIndexHash), MakeField("Event.attending", 0|humus.MetaObject|humus.MetaList|humus.MetaReverse), MakeField("Event.prices", 0|humus.MetaList), MakeField("Event.description", 0), MakeField("~Event.attending|premium", 0|humus.MetaFacet)})
 
 //Generating constant field values.
 const (
@@ -141,7 +141,7 @@ type User struct {
 	Premium   int      `json:"premium" predicate:"Event.attending|premium,omitempty"`
 }
 
-var UserFields humus.Fields = humus.FieldList([]humus.Field{MakeField("User.name", 0), MakeField("User.email", 0), MakeField("User.fullName", 0), MakeField("~Event.attending", 0|humus.MetaObject|humus.MetaList|humus.MetaReverse), MakeField("Event.attending|premium", 0|humus.MetaFacet)})
+var UserFields humus.Fields = humus.FieldList([]humus.Field{MakeField("User.name", 0|humus.MetaIndexHash), MakeField("User.email", 0), MakeField("User.fullName", 0), MakeField("~Event.attending", 0|humus.MetaObject|humus.MetaList|humus.MetaReverse), MakeField("Event.attending|premium", 0|humus.MetaFacet)})
 
 //Generating constant field values.
 const (
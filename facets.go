@@ -1,6 +1,10 @@
 package humus
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 //TODO: Allow proper auto-generation of facets. Is it needed though, is @facets poor performance?
 type facet struct {
@@ -13,6 +17,9 @@ func (f *facetCreator) Paginate(t PaginationType, value int) bool {
 }
 
 func (f *facetCreator) Variable(name string, value string, isAlias bool) bool {
+	if name != "" && !isAlias {
+		f.q.declare(Variable(name))
+	}
 	f.f.m = append(f.f.m, variable{
 		name:  name,
 		value: value,
@@ -25,29 +32,30 @@ func (f *facetCreator) Variable(name string, value string, isAlias bool) bool {
 Applies a filter on the current facet.
 */
 func (f *facetCreator) Filter(t FunctionType, variables ...interface{}) bool {
-	var filter Filter
-	//Only allow one filter as a modifier for now since there is no way to allow connecting filters.
-	for _, v := range f.f.m {
-		if v.priority() == modifierFilter {
-			return false
-		}
+	return f.FilterTree(NewFilter(t, variables...))
+}
+
+/*
+Applies a filter tree on the current facet.
+*/
+func (f *facetCreator) FilterTree(fi *Filter) bool {
+	if fi == nil {
+		return (*mapElement)(f).fail(errors.New("nil filter tree"))
 	}
-	filter.typ = t
-	filter.variables = make([]graphVariable, len(variables))
-	for k, v := range variables {
-		val, typ := processInterface(v)
-		filter.variables[k] = graphVariable{
-			Value: val,
-			Type:  typ,
-		}
+	filter := fi.copy()
+	if err := filter.mapVariables(f.q); err != nil {
+		return (*mapElement)(f).fail(err)
 	}
-	filter.mapVariables(f.q)
 	filter.ignoreHeader = true
-	f.f.m = append(f.f.m, &filter)
+	//Multiple filters on a facet are combined into one.
+	f.f.m = f.f.m.mergeFilter(filter)
 	return true
 }
 
 func (f *facetCreator) Sort(t OrderType, p Predicate) bool {
+	if f.f.m.hasOrdering(p) {
+		return (*mapElement)(f).fail(fmt.Errorf("facet sort on %s set twice", p))
+	}
 	f.f.m = append(f.f.m, Ordering{
 		Type:      t,
 		Predicate: p,
@@ -55,7 +63,35 @@ func (f *facetCreator) Sort(t OrderType, p Predicate) bool {
 	return true
 }
 
+//Value stores the facet p in the value variable v.
+func (f *facetCreator) Value(v Variable, p Predicate) bool {
+	if !validVariableName(string(v)) {
+		return (*mapElement)(f).fail(fmt.Errorf("invalid value variable name %s", v))
+	}
+	f.q.declare(v)
+	f.f.m = append(f.f.m, variable{
+		name:  string(v),
+		value: string(p),
+	})
+	return true
+}
+
+func (f *facetCreator) Language(langs ...Language) bool {
+	return false
+}
+
+func (f *facetCreator) Math(v Variable, expr Math) bool {
+	return false
+}
+
+func (f *facetCreator) SortVariable(t OrderType, v Variable) bool {
+	return false
+}
+
 func (f *facetCreator) Aggregate(t AggregateType, v string, alias string) bool {
+	if t != Count {
+		f.q.use(Variable(v))
+	}
 	f.m = append(f.m, aggregateValues{
 		Type:     t,
 		Alias:    alias,
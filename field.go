@@ -2,6 +2,7 @@ package humus
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -22,6 +23,7 @@ type Fields interface {
 	//Sub allows you to create a sublist of predicates.
 	//If there is an edge on a predicate name, then subbing on that
 	//predicate gets all fields as specified by the fields interfaces.
+	//Aliased fields can also be found using the alias.
 	Sub(name Predicate, fields Fields) Fields
 	//Add a field to this list.
 	Add(fi Field) Fields
@@ -30,6 +32,8 @@ type Fields interface {
 	//Len is the length of the fields.
 	Len() int
 	Select(names ...Predicate) Fields
+	//Alias sets the alias of the field with the predicate name, i.e. alias : name.
+	Alias(name Predicate, alias string) Fields
 }
 
 //Select selects a subset of fields and returns a new list
@@ -120,12 +124,13 @@ func (f FieldList) Sub(name Predicate, fl Fields) Fields {
 
 	//linear search but there are not a lot of values. Hash-map feels overkill
 	for k, v := range newArr {
-		if v.Name == name {
+		if v.Name == name || v.alias != "" && v.alias == string(name) {
 			if fl == nil {
 				newArr[k] = Field{
 					Name:   v.Name,
 					Fields: emptyList,
 					Meta:   v.Meta,
+					alias:  v.alias,
 				}
 				return newArr
 			}
@@ -133,6 +138,7 @@ func (f FieldList) Sub(name Predicate, fl Fields) Fields {
 				Name:   v.Name,
 				Fields: fl,
 				Meta:   v.Meta,
+				alias:  v.alias,
 			}
 			newArr[k] = newField
 			break
@@ -141,6 +147,13 @@ func (f FieldList) Sub(name Predicate, fl Fields) Fields {
 	return newArr
 }
 
+//Alias copies the list and sets the alias of the field with the predicate name.
+func (f FieldList) Alias(name Predicate, alias string) Fields {
+	var newList NewList = make([]Field, len(f))
+	copy(newList, f)
+	return newList.Alias(name, alias)
+}
+
 func (f FieldList) Add(fi Field) Fields {
 	var newList NewList = make([]Field, len(f)+1)
 	copy(newList, f)
@@ -159,12 +172,13 @@ func (f FieldList) AddName(nam Predicate, sch SchemaList) Fields {
 func (f NewList) Sub(name Predicate, fl Fields) Fields {
 	//linear search but fast either way.
 	for k, v := range f {
-		if v.Name == name {
+		if v.Name == name || v.alias != "" && v.alias == string(name) {
 			if fl == nil {
 				f[k] = Field{
 					Name:   v.Name,
 					Fields: emptyList,
 					Meta:   v.Meta,
+					alias:  v.alias,
 				}
 				return f
 			}
@@ -175,6 +189,16 @@ func (f NewList) Sub(name Predicate, fl Fields) Fields {
 	return f
 }
 
+func (f NewList) Alias(name Predicate, alias string) Fields {
+	for k, v := range f {
+		if v.Name == name {
+			f[k].alias = alias
+			break
+		}
+	}
+	return f
+}
+
 //facet adds a field of type facet.
 func (f NewList) Add(fi Field) Fields {
 	return append(f, fi)
@@ -229,7 +253,7 @@ func (c pagination) priority() modifierType {
 //A meta field for schemas.
 //This simply defines properties surrounding fields such as language etc.
 //This is used in generating the queries.
-type FieldMeta uint16
+type FieldMeta uint32
 
 func (f FieldMeta) Lang() bool {
 	return f&MetaLang > 0
@@ -255,10 +279,19 @@ func (f FieldMeta) Empty() bool {
 	return f&MetaEmpty > 0
 }
 
+func (f FieldMeta) Expand() bool {
+	return f&MetaExpand > 0
+}
+
 func (f FieldMeta) Ignore() bool {
 	return f&MetaIgnore > 0 || f&MetaFacet > 0
 }
 
+//Indexed returns whether the predicate has any of the indexes in index.
+func (f FieldMeta) Indexed(index FieldMeta) bool {
+	return f&index > 0
+}
+
 const (
 	MetaObject FieldMeta = 1 << iota
 	MetaList
@@ -268,6 +301,16 @@ const (
 	MetaFacet
 	MetaEmpty
 	MetaIgnore
+	//The index of the predicate as declared using @search.
+	MetaIndexHash
+	MetaIndexExact
+	MetaIndexTerm
+	MetaIndexFulltext
+	MetaIndexTrigram
+	//MetaIndex is any other index, such as int or datetime.
+	MetaIndex
+	//MetaExpand is an expand field, see ExpandAll.
+	MetaExpand
 )
 
 // Field is a recursive data struct which represents a GraphQL query field.
@@ -275,6 +318,38 @@ type Field struct {
 	Meta   FieldMeta
 	Fields Fields
 	Name   Predicate
+	//The key of the field in the result if set.
+	alias string
+}
+
+//As returns the field aliased, i.e. alias : name. The value is returned under
+//the alias and modifiers for the field are applied at the path using the alias.
+//This allows fetching the same predicate several times using different modifiers.
+func (f Field) As(alias string) Field {
+	f.alias = alias
+	return f
+}
+
+//Alias sets the alias if this is the field with the predicate name.
+func (f Field) Alias(name Predicate, alias string) Fields {
+	if f.Name == name {
+		f.alias = alias
+	}
+	return f
+}
+
+//validAlias returns whether the alias is safe to be written into a query.
+//Aliases may contain dots, i.e. Question.title.
+func validAlias(alias string) bool {
+	return alias != "" && alias[0] != '.' && validVariableName(strings.Replace(alias, ".", "_", -1))
+}
+
+//path returns the name of the field in modifier paths.
+func (f *Field) path() Predicate {
+	if f.alias != "" {
+		return Predicate(f.alias)
+	}
+	return f.Name
 }
 
 func (f Field) Select(names ...Predicate) Fields {
@@ -316,6 +391,89 @@ func MakeField(name Predicate, meta FieldMeta) Field {
 	return x
 }
 
+//ExpandAll returns a field fetching all predicates of the types of the node, i.e. expand(_all_).
+//If sub is not nil it selects the fields of the edges, i.e. ExpandAll(ExpandAll(nil))
+//generates expand(_all_){expand(_all_)}.
+func ExpandAll(sub Fields) Field {
+	return Field{Name: "expand(_all_)", Meta: MetaExpand, Fields: sub}
+}
+
+//ExpandType returns a field fetching all predicates of the types, i.e. expand(Question).
+//If sub is not nil it selects the fields of the edges.
+func ExpandType(sub Fields, types ...string) Field {
+	return Field{Name: Predicate("expand(" + strings.Join(types, ",") + ")"), Meta: MetaExpand, Fields: sub}
+}
+
+//checkExpand ensures the types of an expand field are valid.
+func (f *Field) checkExpand() error {
+	name := string(f.Name)
+	if !strings.HasPrefix(name, "expand(") || !strings.HasSuffix(name, ")") {
+		return fmt.Errorf("invalid expand %s", name)
+	}
+	types := name[len("expand(") : len(name)-1]
+	if types == "_all_" {
+		return nil
+	}
+	for _, v := range strings.Split(types, ",") {
+		if !validAlias(v) {
+			return fmt.Errorf("invalid expand type %s", v)
+		}
+	}
+	return nil
+}
+
+//writeName writes the predicate name along with the language if needed.
+//The language of the query is overridden by the language at the path, if any.
+func (f *Field) writeName(q *GeneratedQuery, val *mapElement, sb *strings.Builder) {
+	alias := f.alias
+	//Leaf predicates are aliased in a normalize query.
+	if _, ok := q.normalized[f.Name]; ok && alias == "" && !f.Meta.Object() {
+		alias = string(f.Name)
+	}
+	if alias != "" {
+		sb.WriteString(alias)
+		sb.WriteString(" : ")
+	}
+	sb.WriteString(string(f.Name))
+	if !f.Meta.Lang() {
+		return
+	}
+	langs := q.languages
+	if val != nil && len(val.lang) != 0 {
+		langs = val.lang
+	}
+	if len(langs) != 0 {
+		writeLanguages(langs, sb)
+	}
+}
+
+//createRecurse writes the field for a recurse query. Sub fields are never written
+//as the same fields are used at every level.
+func (f *Field) createRecurse(q *GeneratedQuery, sb *strings.Builder) error {
+	if f.Meta.Ignore() {
+		return nil
+	}
+	if f.alias != "" && !validAlias(f.alias) {
+		return fmt.Errorf("invalid alias %s", f.alias)
+	}
+	if f.Meta.Expand() {
+		if err := f.checkExpand(); err != nil {
+			return err
+		}
+	}
+	val, ok := q.modifiers[f.path()]
+	f.writeName(q, val, sb)
+	if ok {
+		val.m.sort()
+		err := val.m.runNormal(q, f.Meta, modifierField, sb)
+		if err != nil {
+			return err
+		}
+	}
+	sb.WriteByte(' ')
+	return nil
+}
+
 // One may have noticed that there is a public create and a private create.
 // The different being the public method checks the validity of the Field structure
 // while the private counterpart assumes the validity.
@@ -325,6 +483,14 @@ func (f *Field) create(q *GeneratedQuery, parent []byte, sb *strings.Builder) er
 	if f.Meta.Ignore() {
 		return nil
 	}
+	if f.alias != "" && !validAlias(f.alias) {
+		return fmt.Errorf("invalid alias %s", f.alias)
+	}
+	if f.Meta.Expand() {
+		if err := f.checkExpand(); err != nil {
+			return err
+		}
+	}
 	//If a field is an object and has no fields do not use it.
 	val, ok := q.modifiers[Predicate(parent)]
 	var withGroup, withFacets, withFields bool
@@ -333,22 +499,15 @@ func (f *Field) create(q *GeneratedQuery, parent []byte, sb *strings.Builder) er
 		withFacets = val.f.active
 		withFields = withGroup || withFacets
 	}
-	var fieldsExist = f.Fields != nil && f.Fields.Len() == 0
-	if f.Meta.Object() && (!fieldsExist || f.Fields == nil) {
+	//Edges are written once subbed, either with the fields of the node or with an empty
+	//list which only fetches the uid. Edges which are not subbed are not fetched.
+	var fieldsExist = f.Fields != nil
+	if f.Meta.Object() && !fieldsExist {
 		if !withFields {
 			return nil
 		}
 	}
-	if f.Meta.Lang() {
-		sb.WriteString(string(f.Name))
-		sb.WriteByte('@')
-		sb.WriteString(string(q.language))
-		if !q.strictLanguage {
-			sb.WriteString(":.")
-		}
-	} else {
-		sb.WriteString(string(f.Name))
-	}
+	f.writeName(q, val, sb)
 	//First part of modifiers, non-field generating.
 	if ok {
 		val.m.sort()
@@ -370,7 +529,8 @@ func (f *Field) create(q *GeneratedQuery, parent []byte, sb *strings.Builder) er
 		}
 	}
 
-	if fieldsExist {
+	//The aggregates of a group by replace the fields of the edge.
+	if fieldsExist && !withGroup {
 		if f.Meta.Lang() {
 			return errors.New("cannot have language meta and children fields")
 		}
@@ -381,12 +541,13 @@ func (f *Field) create(q *GeneratedQuery, parent []byte, sb *strings.Builder) er
 					if i != 0 {
 						sb.WriteByte(' ')
 					}
-					parent = append(parent, field.Name...)
+					path := field.path()
+					parent = append(parent, path...)
 					err := field.create(q, parent, sb)
 					if err != nil {
 						return err
 					}
-					parent = parent[:len(parent)-len(field.Name)]
+					parent = parent[:len(parent)-len(path)]
 				}
 			}
 		}
@@ -8,20 +8,70 @@ import (
 type logicalOp uint8
 
 const (
-	logicalOr logicalOp = iota
+	//logicalNone marks a leaf filter, i.e. a single function.
+	logicalNone logicalOp = iota
+	logicalOr
 	logicalAnd
 	logicalNot
 )
 
+//The keyword written between the children of a logical filter.
+func (l logicalOp) String() string {
+	switch l {
+	case logicalOr:
+		return " OR "
+	case logicalAnd:
+		return " AND "
+	case logicalNot:
+		return "NOT "
+	}
+	return ""
+}
+
 //Filter represents an object in the query that will be serialized as @filter (function...)
-//It is just a wrapper over a function.
+//It is either a wrapper over a single function or a logical node (AND, OR, NOT)
+//over a list of other filters, which allows building up a full filter expression tree.
 type Filter struct {
-	//	op logicalOp
+	op logicalOp
 	function
-	//	node *Filter
+	nodes        []*Filter
 	ignoreHeader bool
 }
 
+//NewFilter creates a single function filter with the same syntax as Mod.Filter.
+//It can be combined into a filter tree using And, Or and Not and
+//applied using Mod.FilterTree.
+func NewFilter(t FunctionType, variables ...interface{}) *Filter {
+	var filter = new(Filter)
+	filter.typ = t
+	filter.variables = make([]graphVariable, len(variables))
+	for k, v := range variables {
+		val, typ := processInterface(v)
+		filter.variables[k] = graphVariable{
+			Value: val,
+			Type:  typ,
+		}
+	}
+	return filter
+}
+
+//And combines the filters into a filter where all must be true, i.e.
+//f1 AND f2 AND f3.
+func And(filters ...*Filter) *Filter {
+	return &Filter{op: logicalAnd, nodes: filters}
+}
+
+//Or combines the filters into a filter where at least one must be true, i.e.
+//f1 OR f2 OR f3.
+func Or(filters ...*Filter) *Filter {
+	return &Filter{op: logicalOr, nodes: filters}
+}
+
+//Not negates the filter f, i.e. NOT f.
+func Not(f *Filter) *Filter {
+	return &Filter{op: logicalNot, nodes: []*Filter{f}}
+}
+
 func (f *Filter) canApply(mt modifierSource) bool {
 	return true
 }
@@ -39,20 +89,125 @@ func (f *Filter) parenthesis() bool {
 	return false
 }
 
-func (f *Filter) stringify(q *GeneratedQuery, sb *strings.Builder) error {
-	if f != nil {
-		err := f.function.create(q, sb)
-		if err != nil {
+//leaf returns whether this filter is a single function.
+func (f *Filter) leaf() bool {
+	return f.op == logicalNone
+}
+
+//copy returns a deep copy of the filter tree. Since mapping variables
+//modifies the filter a user supplied tree is always copied before use.
+func (f *Filter) copy() *Filter {
+	if f == nil {
+		return nil
+	}
+	var newFilter = &Filter{
+		op:           f.op,
+		ignoreHeader: f.ignoreHeader,
+	}
+	newFilter.typ = f.typ
+	if f.variables != nil {
+		newFilter.variables = make([]graphVariable, len(f.variables))
+		copy(newFilter.variables, f.variables)
+	}
+	if f.nodes != nil {
+		newFilter.nodes = make([]*Filter, len(f.nodes))
+		for k, v := range f.nodes {
+			newFilter.nodes[k] = v.copy()
+		}
+	}
+	return newFilter
+}
+
+//mapVariables maps the GraphQL variables of every function in the tree
+//in the order they are written.
+func (f *Filter) mapVariables(q *GeneratedQuery) error {
+	//Nil filters are reported by check.
+	if f == nil {
+		return nil
+	}
+	if f.leaf() {
+		return f.function.mapVariables(q)
+	}
+	for _, v := range f.nodes {
+		if err := v.mapVariables(q); err != nil {
 			return err
 		}
-	} else {
+	}
+	return nil
+}
+
+func (f *Filter) check(q *GeneratedQuery) error {
+	if f == nil {
 		return errors.New("missing function in humus filter")
 	}
+	switch f.op {
+	case logicalNone:
+		return f.function.check(q)
+	case logicalNot:
+		if len(f.nodes) != 1 {
+			return errors.New("not filter requires exactly one filter")
+		}
+	default:
+		if len(f.nodes) == 0 {
+			return errors.New("empty logical filter")
+		}
+	}
+	for _, v := range f.nodes {
+		if err := v.check(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//needsParenthesis returns whether child has to be wrapped in parenthesis
+//when written as a direct child of f. Dgraph does not parse NOT NOT.
+func (f *Filter) needsParenthesis(child *Filter) bool {
+	if child.leaf() {
+		return false
+	}
+	if child.op == logicalNot {
+		return f.op == logicalNot
+	}
+	return f.op == logicalNot || child.op != f.op
+}
+
+func (f *Filter) stringify(q *GeneratedQuery, sb *strings.Builder) error {
+	if f == nil {
+		return errors.New("missing function in humus filter")
+	}
+	if f.leaf() {
+		return f.function.create(q, sb)
+	}
+	if f.op == logicalNot {
+		sb.WriteString(f.op.String())
+	}
+	for k, v := range f.nodes {
+		if k != 0 {
+			sb.WriteString(f.op.String())
+		}
+		//Single valued groups are written as their child.
+		for !v.leaf() && v.op != logicalNot && len(v.nodes) == 1 {
+			v = v.nodes[0]
+		}
+		paren := f.needsParenthesis(v)
+		if paren {
+			sb.WriteByte('(')
+		}
+		if err := v.stringify(q, sb); err != nil {
+			return err
+		}
+		if paren {
+			sb.WriteByte(')')
+		}
+	}
 	return nil
 }
 
 func (f *Filter) create(q *GeneratedQuery, sb *strings.Builder) error {
-	//No nil checks. Done during check.
+	if err := f.check(q); err != nil {
+		return err
+	}
 	if !f.ignoreHeader {
 		sb.WriteString(tokenFilter)
 		sb.WriteByte('(')
@@ -1,5 +1,11 @@
 package humus
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
 //StaticQuery represents a static query.
 type StaticQuery struct {
 	Query string
@@ -42,3 +48,117 @@ func (s StaticQuery) QueryWithVars(vars map[string]string) StaticQuery {
 		vars:  vars,
 	}
 }
+
+//StaticBlock represents a block written by hand in a multiple query,
+//i.e. user as var(func: eq(User.name, $name)). The GraphQL variables used
+//in the block are declared using Var and included in the query header.
+type StaticBlock struct {
+	block   string
+	name    string
+	keys    []string
+	types   []string
+	values  []string
+	err     error
+	varMap  map[string]string
+	varDecl string
+}
+
+//Static adds a static block to the list of queries. Name is the name of the block
+//in the result and should be empty for var blocks. As for GeneratedQuery.Name
+//names of the form q + index are reserved.
+func (q *Queries) Static(name string, block string) *StaticBlock {
+	s := &StaticBlock{
+		block:  block,
+		name:   name,
+		varMap: q.vars,
+	}
+	if reservedName(name) {
+		s.err = fmt.Errorf("reserved static block name %s", name)
+	}
+	q.q = append(q.q, s)
+	return s
+}
+
+//staticTypes are the types allowed for GraphQL variables in a static block.
+var staticTypes = map[string]struct{}{
+	"string": {},
+	"int":    {},
+	"float":  {},
+	"bool":   {},
+}
+
+//Var declares the GraphQL variable key, i.e. $name, of type typ used in the block.
+//Keys are shared between all blocks in the query and may not be numeric as these
+//are used for generated variables.
+func (s *StaticBlock) Var(key string, typ string, value interface{}) *StaticBlock {
+	if len(key) < 2 || key[0] != '$' || !validVariableName(key[1:]) || key[1] >= '0' && key[1] <= '9' {
+		s.err = fmt.Errorf("invalid variable %s in static block", key)
+		return s
+	}
+	if _, ok := staticTypes[strings.TrimSuffix(typ, "!")]; !ok {
+		s.err = fmt.Errorf("invalid variable type %s in static block", typ)
+		return s
+	}
+	val, _ := processInterface(value)
+	s.keys = append(s.keys, key)
+	s.types = append(s.types, typ)
+	s.values = append(s.values, val)
+	return s
+}
+
+func (s *StaticBlock) mapBlock() {
+	var sb strings.Builder
+	for k, key := range s.keys {
+		if k != 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(key)
+		sb.WriteByte(':')
+		sb.WriteString(s.types[k])
+		//Keys are shared between blocks but must have the same value.
+		if old, ok := s.varMap[key]; ok && old != s.values[k] && s.err == nil {
+			s.err = fmt.Errorf("variable %s set to different values", key)
+		}
+		s.varMap[key] = s.values[k]
+	}
+	s.varDecl = sb.String()
+}
+
+func (s *StaticBlock) variables() string {
+	return s.varDecl
+}
+
+//declares returns the value variables declared in the block, i.e. the x in x as var(...).
+func (s *StaticBlock) declares() []Variable {
+	var ret []Variable
+	fields := strings.Fields(s.block)
+	for k := 1; k < len(fields); k++ {
+		if fields[k] == "as" && validVariableName(fields[k-1]) {
+			ret = append(ret, Variable(fields[k-1]))
+		}
+	}
+	return ret
+}
+
+//checkVariables is a no-op as the usage of value variables in a static block is not known.
+func (s *StaticBlock) checkVariables(declared map[Variable]struct{}) error {
+	return nil
+}
+
+func (s *StaticBlock) names() []string {
+	if s.name == "" {
+		return nil
+	}
+	return []string{s.name}
+}
+
+func (s *StaticBlock) create(sb *strings.Builder) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	if strings.TrimSpace(s.block) == "" {
+		return "", errors.New("empty static block")
+	}
+	sb.WriteString(s.block)
+	return sb.String(), nil
+}
@@ -30,18 +30,106 @@ func (s Predicate) String() string {
 const (
 	Equals      FunctionType = "eq"
 	AllOfText   FunctionType = "alloftext"
+	AnyOfText   FunctionType = "anyoftext"
 	AllOfTerms  FunctionType = "allofterms"
 	AnyOfTerms  FunctionType = "anyofterms"
 	FunctionUid FunctionType = "uid"
+	UidIn       FunctionType = "uid_in"
 	Has         FunctionType = "has"
 	LessEq      FunctionType = "le"
 	Match       FunctionType = "match"
+	Regexp      FunctionType = "regexp"
 	Less        FunctionType = "lt"
 	GreaterEq   FunctionType = "ge"
 	Greater     FunctionType = "gt"
+	Between     FunctionType = "between"
 	Type        FunctionType = "type"
+	Near        FunctionType = "near"
+	Within      FunctionType = "within"
+	Contains    FunctionType = "contains"
+	Intersects  FunctionType = "intersects"
+	SimilarTo   FunctionType = "similar_to"
 )
 
+//argKind is a bitmask of the variable types allowed
+//for an argument in a function.
+type argKind uint8
+
+const (
+	argPred argKind = 1 << iota
+	argVar
+	argString
+	argInt
+	argFloat
+	argUid
+	argGeo
+	argVector
+	//Any scalar value to compare against.
+	argValue  = argString | argInt | argFloat
+	argNumber = argInt | argFloat
+)
+
+//signature is the list of arguments for a function. If variadic the last
+//argument may be repeated.
+type signature struct {
+	args     []argKind
+	variadic bool
+}
+
+//signatures contains the argument list for all known functions. Functions
+//not in this map are not checked, which allows for custom functions.
+var signatures = map[FunctionType]signature{
+	//Comparisons, which on strings are the exact variants using the exact index.
+	Equals:    {args: []argKind{argPred | argVar, argValue | argVar}, variadic: true},
+	LessEq:    {args: []argKind{argPred | argVar, argValue | argVar}},
+	Less:      {args: []argKind{argPred | argVar, argValue | argVar}},
+	GreaterEq: {args: []argKind{argPred | argVar, argValue | argVar}},
+	Greater:   {args: []argKind{argPred | argVar, argValue | argVar}},
+	Between:   {args: []argKind{argPred, argValue, argValue}},
+	//Term variants using the term index.
+	AllOfTerms: {args: []argKind{argPred, argString}},
+	AnyOfTerms: {args: []argKind{argPred, argString}},
+	//Full text variants using the fulltext index.
+	AllOfText: {args: []argKind{argPred, argString}},
+	AnyOfText: {args: []argKind{argPred, argString}},
+	//Regular expressions and fuzzy matching using the trigram index.
+	Regexp: {args: []argKind{argPred, argString}},
+	Match:  {args: []argKind{argPred, argString, argInt}},
+
+	Has:         {args: []argKind{argPred}},
+	Type:        {args: []argKind{argString}},
+	FunctionUid: {args: []argKind{argUid | argVar}, variadic: true},
+	UidIn:       {args: []argKind{argPred, argUid | argVar}, variadic: true},
+	Near:        {args: []argKind{argPred, argGeo, argNumber}},
+	Within:      {args: []argKind{argPred, argGeo}},
+	Contains:    {args: []argKind{argPred, argGeo}},
+	Intersects:  {args: []argKind{argPred, argGeo}},
+	SimilarTo:   {args: []argKind{argPred, argInt, argVector | argString}},
+}
+
+//kind returns the argument kind of a variable type.
+func (v varType) kind() argKind {
+	switch v {
+	case typePred:
+		return argPred
+	case typeVar:
+		return argVar
+	case typeString:
+		return argString
+	case typeInt:
+		return argInt
+	case typeFloat:
+		return argFloat
+	case typeUid:
+		return argUid
+	case typeGeo:
+		return argGeo
+	case typeVector:
+		return argVector
+	}
+	return 0
+}
+
 const (
 	Ascending  OrderType = "orderasc"
 	Descending OrderType = "orderdesc"
@@ -83,14 +171,35 @@ const (
 	typeGeo     varType = "geo"
 	typeDefault varType = ""
 	typeVar     varType = "val"
+	typeVector  varType = "float32vector"
 )
 
+//graphType returns the type of the variable as declared in the query header.
+//Uids are declared as strings.
+func (v varType) graphType() varType {
+	if v == typeUid {
+		return typeString
+	}
+	return v
+}
+
 //graphVariable represents a variable before it is parsed and written into a query.
 type graphVariable struct {
 	Value string
 	Type  varType
 }
 
+//check returns an error if the value can not be written into the query as is.
+func (v graphVariable) check() error {
+	switch {
+	case v.Type == typeVar && !validVariableName(v.Value):
+		return fmt.Errorf("invalid value variable name %s", v.Value)
+	case v.Type == typeUid && len(v.Value) > 16:
+		return fmt.Errorf("invalid uid %s", v.Value)
+	}
+	return nil
+}
+
 //function represents a GraphQL+- function. It writes into the query
 //the function type, checks the type as well as the list of arguments.
 //It uses GraphQL variables to minimize risk of any type of injection.
@@ -99,36 +208,6 @@ type function struct {
 	variables []graphVariable
 }
 
-//OR, NOT, AND implements basic logicals.
-//This is not a fully featured function, made for simple examples such as single and or single OR
-//Not working right now. For complex filters just use fix queries for now.
-
-/*
-func (f *function) OR(fi *Filter) *Filter {
-	return &Filter{
-		op:            logicalOr,
-		function: *f,
-		node:          fi,
-	}
-}
-
-func (f *function) NOT(fi *Filter) *Filter {
-	return &Filter{
-		op:            logicalNot,
-		function: *f,
-		node:          fi,
-	}
-}
-
-func (f *function) AND(fi *Filter) *Filter {
-	return &Filter{
-		op:            logicalAnd,
-		function: *f,
-		node:          fi,
-	}
-}
-
-*/
 func (f *function) values(val []interface{}) *function {
 	if f.variables == nil {
 		f.variables = make([]graphVariable, len(val))
@@ -162,16 +241,40 @@ func (f *function) predValues(pred Predicate, values []interface{}) *function {
 	return f
 }
 
-func (f *function) mapVariables(q *GeneratedQuery) {
+//predicate returns the predicate of the function, if any.
+func (f *function) predicate() Predicate {
+	for _, v := range f.variables {
+		if v.Type == typePred {
+			return Predicate(v.Value)
+		}
+	}
+	return ""
+}
+
+//mapVariables maps the values of the function to GraphQL variables. Values written
+//as is are checked, returning an error on values which could alter the query.
+func (f *function) mapVariables(q *GeneratedQuery) error {
 	for k, v := range f.variables {
-		//Handle the two special cases that do not need variable mapping.
+		//Handle the special cases that do not need variable mapping.
 		if v.Type == typePred {
 			continue
 		}
+		if err := v.check(); err != nil {
+			return err
+		}
+		//Variables are not GraphQL mapped but written as is, i.e. val(x).
+		if v.Type == typeVar {
+			q.use(Variable(v.Value))
+			continue
+		}
 		if v.Type == typeUid {
-			if len(v.Value) > 16 {
-				panic("invalid UID, this could be an SQL injection.")
+			//Prepared queries bind uids using GraphQL variables.
+			if !q.prepared {
+				continue
 			}
+		}
+		//Geo values are built from numbers only and written as is.
+		if v.Type == typeGeo {
 			continue
 		}
 		//Do not cause variable names in a function to be GraphQL mapped.
@@ -179,9 +282,10 @@ func (f *function) mapVariables(q *GeneratedQuery) {
 			continue
 		}
 		//Build the variable using the integer from the query.
-		key := q.registerVariable(v.Type, v.Value)
+		key := q.registerVariable(v.Type, v.Value, f.predicate())
 		f.variables[k].Value = key
 	}
+	return nil
 }
 
 func (f *function) create(q *GeneratedQuery, sb *strings.Builder) error {
@@ -208,9 +312,16 @@ func (f *function) buildVarString(sb *strings.Builder) {
 				Handle custom functions.
 			*/
 		case typeUid:
+			//Uids mapped to GraphQL variables in prepared queries are written as is.
+			if strings.HasPrefix(v.Value, "$") {
+				sb.WriteString(v.Value)
+				break
+			}
 			sb.WriteByte('"')
 			sb.WriteString(v.Value)
 			sb.WriteByte('"')
+		case typeVar:
+			f.writeVariable(k, v.Value, sb)
 		default:
 			sb.WriteString(v.Value)
 		}
@@ -223,6 +334,23 @@ func (f *function) buildVarString(sb *strings.Builder) {
 	}
 }
 
+//writeVariable writes the variable at index k. Variables are uid variables
+//in uid functions and otherwise value variables.
+func (f *function) writeVariable(k int, name string, sb *strings.Builder) {
+	switch {
+	case f.typ == FunctionUid, k == 0 && strings.IndexByte(string(f.typ), '(') != -1:
+		sb.WriteString(name)
+	case f.typ == UidIn:
+		sb.WriteString("uid(")
+		sb.WriteString(name)
+		sb.WriteByte(')')
+	default:
+		sb.WriteString("val(")
+		sb.WriteString(name)
+		sb.WriteByte(')')
+	}
+}
+
 func (f *function) check(q *GeneratedQuery) error {
 	if f.typ == "" {
 		return errMissingFunction
@@ -230,12 +358,42 @@ func (f *function) check(q *GeneratedQuery) error {
 	if len(f.variables) == 0 {
 		return errMissingVariables
 	}
-	switch f.typ {
-	case Has:
-		if len(f.variables) != 1 && f.variables[0].Type != typePred {
-			return fmt.Errorf("%s function too many variables or invalid types, have %v need %v", f.typ, len(f.variables), 1)
+	for _, v := range f.variables {
+		if err := v.check(); err != nil {
+			return err
+		}
+	}
+	//Functions with a subfunction, i.e. lt(count(pred)..., are checked on the outer function.
+	name := f.typ
+	sub := false
+	if index := strings.IndexByte(string(f.typ), '('); index != -1 {
+		name = f.typ[:index]
+		sub = true
+	}
+	sig, ok := signatures[name]
+	if !ok {
+		return nil
+	}
+	if len(f.variables) < len(sig.args) || (!sig.variadic && len(f.variables) != len(sig.args)) {
+		return fmt.Errorf("%s function invalid amount of variables, have %v need %v", name, len(f.variables), len(sig.args))
+	}
+	for k, v := range f.variables {
+		var allowed argKind
+		if k < len(sig.args) {
+			allowed = sig.args[k]
+		} else {
+			allowed = sig.args[len(sig.args)-1]
+		}
+		//The first value of a subfunction is written as is, i.e. val(name).
+		if k == 0 && sub {
+			allowed |= argString
+		}
+		if v.Type.kind()&allowed == 0 {
+			return fmt.Errorf("%s function invalid type for variable %v, have %s", name, k, v.Type)
+		}
+		if v.Type == typeGeo && v.Value == "" {
+			return fmt.Errorf("%s function empty geo value for variable %v", name, k)
 		}
-		break
 	}
 	return nil
 }
@@ -183,6 +183,7 @@ func makeFieldList(name string, fi []Field, sb *bytes.Buffer, allowUnderscore bo
 		if v.flags&flagLang != 0 {
 			flagBuilder.WriteString("|humus.MetaLang")
 		}
+		flagBuilder.WriteString(indexMeta(&v))
 		nofield := v.HasDirective("ignore") != nil
 		if nofield {
 			flagBuilder.WriteString("|humus.MetaIgnore")
@@ -198,6 +199,44 @@ func makeFieldList(name string, fi []Field, sb *bytes.Buffer, allowUnderscore bo
 	sb.WriteString(fmt.Sprintf(fieldDecl, name, isb.String()) + "\n")
 }
 
+//indexMeta returns the index metadata from the search directive, i.e. |humus.MetaIndexHash.
+func indexMeta(f *Field) string {
+	dir := f.HasDirective("search")
+	if dir == nil {
+		return ""
+	}
+	by, ok := dir.Args.Get("by")
+	if !ok {
+		return ""
+	}
+	var indexes []string
+	if list, ok := by.(*common.ListLit); ok {
+		for _, v := range list.Entries {
+			indexes = append(indexes, v.String())
+		}
+	} else {
+		indexes = append(indexes, by.String())
+	}
+	var sb strings.Builder
+	for _, v := range indexes {
+		switch v {
+		case "hash":
+			sb.WriteString("|humus.MetaIndexHash")
+		case "exact":
+			sb.WriteString("|humus.MetaIndexExact")
+		case "term":
+			sb.WriteString("|humus.MetaIndexTerm")
+		case "fulltext":
+			sb.WriteString("|humus.MetaIndexFulltext")
+		case "trigram":
+			sb.WriteString("|humus.MetaIndexTrigram")
+		default:
+			sb.WriteString("|humus.MetaIndex")
+		}
+	}
+	return sb.String()
+}
+
 //Create the field declaration as well as the Field object. These field objects are used in template generation.
 //This ensures the values in json tags will match the database.
 //directive name is used for facet.
@@ -214,6 +253,10 @@ func createField(objectName string, name string, typ string, sb *bytes.Buffer, f
 	}
 	fi.TypeLabel += typ
 	fi.Type = typ
+	//Language fields hold the value in several languages.
+	if flag&flagLang != 0 && flag&flagArray == 0 && typ == "string" {
+		fi.TypeLabel = "humus.LangString"
+	}
 
 	//Do not capitalize the tag.
 	var dbName = objectName + "." + name
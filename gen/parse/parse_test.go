@@ -1,6 +1,8 @@
 package parse
 
 import (
+	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 )
@@ -36,3 +38,31 @@ func TestDuplicate(t *testing.T) {
 		Package: "gen",
 	})
 }
+
+func TestLang(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	Parse(&Config{
+		State:   "dgraph",
+		Input:   "testdata/lang/",
+		Output:  dir,
+		Package: "gen",
+	})
+	models, err := ioutil.ReadFile(dir + ModelFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(models), "humus.LangString") {
+		t.Errorf("missing LangString field in models")
+	}
+	sch, err := ioutil.ReadFile(dir + "/schema.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sch), "@lang") {
+		t.Errorf("missing @lang in schema")
+	}
+}
@@ -0,0 +1,145 @@
+package humus
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+//Groups is the result of a @groupby block at the root or at an edge. Dgraph returns
+//the groups nested under a @groupby key, i.e. [{"@groupby":[{"Post.author":"0x1","count":2}]}],
+//which is unwrapped when decoding. For a group by at the root use it as the value in Query,
+//for a group by at an edge use it as the field of the edge in a struct, i.e.
+//
+//	type AuthorGroups struct {
+//		Comments humus.Groups `predicate:"Post.comments"`
+//	}
+type Groups struct {
+	raw []jsoniter.RawMessage
+}
+
+//GroupRow is a single group, the value grouped on along with the aggregate values.
+type GroupRow struct {
+	//Key is the value of the grouping predicate, a string uid for edge predicates.
+	Key interface{}
+	//Values are the raw aggregates keyed by name or alias, i.e. count. Use the
+	//accessors as the type depends on the aggregate, i.e. max over a datetime.
+	Values map[string]jsoniter.RawMessage
+}
+
+//UID returns the key as a uid for a group by on an edge predicate.
+func (g GroupRow) UID() UID {
+	s, _ := g.Key.(string)
+	return UID(s)
+}
+
+//Count returns the value of count(uid).
+func (g GroupRow) Count() int {
+	var i int
+	_ = g.Value("count", &i)
+	return i
+}
+
+//Value decodes the aggregate with the name into v.
+func (g GroupRow) Value(name string, v interface{}) error {
+	val, ok := g.Values[name]
+	if !ok {
+		return fmt.Errorf("group without value %s", name)
+	}
+	return json.Unmarshal(val, v)
+}
+
+//Float returns the aggregate with the name as a number, i.e. sum or avg.
+func (g GroupRow) Float(name string) (float64, error) {
+	var f float64
+	err := g.Value(name, &f)
+	return f, err
+}
+
+//String returns the aggregate with the name as a string, i.e. min over a string predicate.
+func (g GroupRow) String(name string) (string, error) {
+	var s string
+	err := g.Value(name, &s)
+	return s, err
+}
+
+//Time returns the aggregate with the name as a time, i.e. max over a datetime predicate.
+func (g GroupRow) Time(name string) (time.Time, error) {
+	var t time.Time
+	err := g.Value(name, &t)
+	return t, err
+}
+
+//groupBlock is the object holding the groups.
+type groupBlock struct {
+	Groups []jsoniter.RawMessage `predicate:"@groupby"`
+}
+
+//UnmarshalJSON decodes the groups from a single object or a list of them.
+func (g *Groups) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || string(b) == "null" {
+		return nil
+	}
+	var blocks []groupBlock
+	if b[0] == '[' {
+		if err := json.Unmarshal(b, &blocks); err != nil {
+			return err
+		}
+	} else {
+		blocks = make([]groupBlock, 1)
+		if err := json.Unmarshal(b, &blocks[0]); err != nil {
+			return err
+		}
+	}
+	g.raw = g.raw[:0]
+	for _, v := range blocks {
+		g.raw = append(g.raw, v.Groups...)
+	}
+	return nil
+}
+
+//Len returns the amount of groups.
+func (g Groups) Len() int {
+	return len(g.raw)
+}
+
+//Rows returns the groups as rows with the key set from the grouping predicate p,
+//i.e. the onWhich predicate in GroupBy. All other values are aggregates.
+func (g Groups) Rows(p Predicate) ([]GroupRow, error) {
+	var rows = make([]GroupRow, 0, len(g.raw))
+	for _, v := range g.raw {
+		var m map[string]jsoniter.RawMessage
+		if err := json.Unmarshal(v, &m); err != nil {
+			return nil, err
+		}
+		key, ok := m[string(p)]
+		if !ok {
+			return nil, fmt.Errorf("group without predicate %s", p)
+		}
+		delete(m, string(p))
+		var row = GroupRow{Values: m}
+		if err := json.Unmarshal(key, &row.Key); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+//Decode decodes the groups into v, a pointer to a slice of structs with predicate tags
+//for the grouping predicate and the aggregates, i.e.
+//
+//	type AuthorCount struct {
+//		Author humus.UID `predicate:"Post.author"`
+//		Count  int       `predicate:"count"`
+//	}
+func (g Groups) Decode(v interface{}) error {
+	b, err := json.Marshal(g.raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
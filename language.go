@@ -1,14 +1,321 @@
 package humus
 
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/modern-go/reflect2"
+)
+
 //Language represents a language that sets relevant queries to the language as specified.
+//Any BCP-47 tag is allowed, i.e. en, sv or zh-Hant.
 type Language string
 
-//A list of possible languages.
-//TODO: Do not make these static constants. Allow arbitrary languages.
+//A list of common languages.
 const (
 	LanguageEnglish = "en"
 	LanguageGerman  = "de"
 	LanguageSwedish = "se"
 	//Same as english.
 	LanguageNone = ""
+	//LanguageUntagged falls back to the untagged value, or any language if missing.
+	//It is only valid as the last language in a chain.
+	LanguageUntagged = "."
+	//LanguageAll fetches the values in all languages. It is only valid by itself
+	//and is used to load a LangString.
+	LanguageAll = "*"
 )
+
+//valid returns whether the language is a BCP-47 tag, i.e. letters
+//followed by any amount of alphanumerical subtags separated by '-'.
+func (l Language) valid() bool {
+	if l == "" {
+		return false
+	}
+	for k, v := range strings.Split(string(l), "-") {
+		if len(v) == 0 || len(v) > 8 {
+			return false
+		}
+		for _, c := range v {
+			switch {
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			case c >= '0' && c <= '9' && k != 0:
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//checkLanguages ensures the languages form a valid fallback chain.
+func checkLanguages(langs []Language) error {
+	for k, v := range langs {
+		switch {
+		case v == LanguageAll:
+			if len(langs) != 1 {
+				return fmt.Errorf("language %s must be used by itself", v)
+			}
+		case v == LanguageUntagged:
+			if k != len(langs)-1 {
+				return fmt.Errorf("language %s must be last", v)
+			}
+		case !v.valid():
+			return fmt.Errorf("invalid language %s", v)
+		}
+	}
+	return nil
+}
+
+//writeLanguages writes the fallback chain, i.e. @sv:en:.
+func writeLanguages(langs []Language, sb *strings.Builder) {
+	sb.WriteByte('@')
+	for k, v := range langs {
+		if k != 0 {
+			sb.WriteByte(':')
+		}
+		sb.WriteString(string(v))
+	}
+}
+
+//LangString holds the values of a @lang predicate in several languages, keyed by language.
+//The untagged value is keyed by LanguageNone. Values fetched using a fallback chain are
+//keyed by the chain, i.e. sv:en:. and values are saved in every language in the map.
+type LangString map[Language]string
+
+//Get returns the value in the first language found.
+func (l LangString) Get(langs ...Language) string {
+	for _, v := range langs {
+		if val, ok := l[v]; ok {
+			return val
+		}
+	}
+	return ""
+}
+
+//UnmarshalJSON sets the untagged value. Language tagged values are set
+//when decoding the node holding the LangString.
+func (l *LangString) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if *l == nil {
+		*l = make(LangString)
+	}
+	(*l)[LanguageNone] = s
+	return nil
+}
+
+//MarshalJSON writes the untagged value. Language tagged values are written
+//when encoding the node holding the LangString.
+func (l LangString) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l[LanguageNone])
+}
+
+//structField is a field of a node with a LangString field, keyed by its predicate.
+type structField struct {
+	pred      string
+	index     []int
+	omitEmpty bool
+	lang      bool
+}
+
+//structFields returns the fields of the struct by predicate, including the fields
+//of embedded structs, where the shallowest field wins as in encoding/json.
+func structFields(typ reflect.Type) []structField {
+	var fields []structField
+	var langType = reflect.TypeOf(LangString{})
+	var walk func(typ reflect.Type, index []int)
+	walk = func(typ reflect.Type, index []int) {
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			tag, ok := f.Tag.Lookup("predicate")
+			if f.PkgPath != "" && !f.Anonymous || tag == "-" {
+				continue
+			}
+			idx := append(append([]int{}, index...), i)
+			if f.Anonymous && !ok && f.Type.Kind() == reflect.Struct {
+				walk(f.Type, idx)
+				continue
+			}
+			if f.PkgPath != "" {
+				continue
+			}
+			var field = structField{pred: f.Name, index: idx, lang: f.Type == langType}
+			if ok {
+				opts := strings.Split(tag, ",")
+				if opts[0] != "" {
+					field.pred = opts[0]
+				}
+				for _, v := range opts[1:] {
+					field.omitEmpty = field.omitEmpty || v == "omitempty"
+				}
+			}
+			fields = append(fields, field)
+		}
+	}
+	walk(typ, nil)
+	var ret = make([]structField, 0, len(fields))
+loop:
+	for k, v := range fields {
+		for i, other := range fields {
+			//Fields at the same depth hide each other.
+			if i != k && other.pred == v.pred && len(other.index) <= len(v.index) {
+				continue loop
+			}
+		}
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+//langExtension handles nodes with LangString fields as Dgraph represents language
+//tagged values as separate keys, i.e. User.name@en, which are folded into the LangString.
+type langExtension struct {
+	jsoniter.DummyExtension
+}
+
+//hasLang returns whether the type is a struct with a LangString field.
+func hasLang(typ reflect.Type) ([]structField, bool) {
+	if typ.Kind() != reflect.Struct {
+		return nil, false
+	}
+	fields := structFields(typ)
+	for _, v := range fields {
+		if v.lang {
+			return fields, true
+		}
+	}
+	return nil, false
+}
+
+func (*langExtension) DecorateDecoder(typ reflect2.Type, dec jsoniter.ValDecoder) jsoniter.ValDecoder {
+	fields, ok := hasLang(typ.Type1())
+	if !ok {
+		return dec
+	}
+	return &langCodec{typ: typ.Type1(), fields: fields}
+}
+
+func (*langExtension) DecorateEncoder(typ reflect2.Type, enc jsoniter.ValEncoder) jsoniter.ValEncoder {
+	fields, ok := hasLang(typ.Type1())
+	if !ok {
+		return enc
+	}
+	return &langCodec{typ: typ.Type1(), fields: fields}
+}
+
+//langCodec decodes and encodes a node with LangString fields field by field.
+type langCodec struct {
+	typ    reflect.Type
+	fields []structField
+}
+
+func (c *langCodec) field(pred string) *structField {
+	for k := range c.fields {
+		if c.fields[k].pred == pred {
+			return &c.fields[k]
+		}
+	}
+	return nil
+}
+
+func (c *langCodec) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	if iter.ReadNil() {
+		return
+	}
+	val := reflect.NewAt(c.typ, ptr).Elem()
+	iter.ReadObjectCB(func(it *jsoniter.Iterator, key string) bool {
+		pred, lang := key, ""
+		if i := strings.IndexByte(key, '@'); i != -1 {
+			pred, lang = key[:i], key[i+1:]
+		}
+		f := c.field(pred)
+		switch {
+		case f == nil, lang != "" && !f.lang:
+			it.Skip()
+		case lang != "":
+			l := val.FieldByIndex(f.index).Addr().Interface().(*LangString)
+			if *l == nil {
+				*l = make(LangString)
+			}
+			(*l)[Language(lang)] = it.ReadString()
+		default:
+			it.ReadVal(val.FieldByIndex(f.index).Addr().Interface())
+		}
+		return true
+	})
+}
+
+func (c *langCodec) IsEmpty(ptr unsafe.Pointer) bool {
+	return false
+}
+
+func (c *langCodec) Encode(ptr unsafe.Pointer, stream *jsoniter.Stream) {
+	val := reflect.NewAt(c.typ, ptr).Elem()
+	var more bool
+	field := func(key string) {
+		if more {
+			stream.WriteMore()
+		}
+		more = true
+		stream.WriteObjectField(key)
+	}
+	stream.WriteObjectStart()
+	for _, v := range c.fields {
+		fv := val.FieldByIndex(v.index)
+		if !v.lang {
+			if v.omitEmpty && emptyValue(fv) {
+				continue
+			}
+			field(v.pred)
+			stream.WriteVal(fv.Interface())
+			continue
+		}
+		l := fv.Interface().(LangString)
+		var langs = make([]string, 0, len(l))
+		for lang := range l {
+			//Values fetched using a fallback chain can not be saved.
+			if lang == LanguageNone || lang.valid() {
+				langs = append(langs, string(lang))
+			}
+		}
+		sort.Strings(langs)
+		for _, lang := range langs {
+			key := v.pred
+			if lang != LanguageNone {
+				key += "@" + lang
+			}
+			field(key)
+			stream.WriteString(l[Language(lang)])
+		}
+	}
+	stream.WriteObjectEnd()
+}
+
+//emptyValue returns whether the value is empty as in omitempty.
+func emptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
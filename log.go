@@ -0,0 +1,198 @@
+package humus
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/dgo/protos/api"
+)
+
+//LogLevel is the level of a log entry.
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "unknown"
+}
+
+//LogField is a single structured field in a log entry.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+//Logger is a leveled structured logger. Implement it to use the logger of the
+//application, see NewStdLogger for the standard library logger.
+type Logger interface {
+	Log(level LogLevel, msg string, fields ...LogField)
+}
+
+//stdLogger writes entries at or above level to a standard library logger.
+type stdLogger struct {
+	l     *log.Logger
+	level LogLevel
+}
+
+//NewStdLogger returns a Logger writing entries at or above level to l,
+//or the standard logger if l is nil, as msg key=value.
+func NewStdLogger(l *log.Logger, level LogLevel) Logger {
+	if l == nil {
+		l = log.New(log.Writer(), log.Prefix(), log.Flags())
+	}
+	return &stdLogger{l: l, level: level}
+}
+
+func (s *stdLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	if level < s.level {
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString(level.String())
+	sb.WriteByte(' ')
+	sb.WriteString(msg)
+	for _, v := range fields {
+		sb.WriteByte(' ')
+		sb.WriteString(v.Key)
+		sb.WriteByte('=')
+		fmt.Fprint(&sb, v.Value)
+	}
+	s.l.Print(sb.String())
+}
+
+//redacted replaces the values of redacted predicates in logs.
+const redacted = "***"
+
+//redactor masks the values of predicates in logged requests.
+type redactor map[Predicate]struct{}
+
+//newRedactor returns a redactor for the predicates along with the MetaIgnore fields in the schema.
+func newRedactor(sch SchemaList, preds []Predicate) redactor {
+	var r = make(redactor)
+	for k, v := range sch {
+		if v.Meta&MetaIgnore != 0 {
+			r[k] = struct{}{}
+		}
+	}
+	for _, v := range preds {
+		r[v] = struct{}{}
+	}
+	return r
+}
+
+//vars returns the variables with the values compared to a redacted predicate masked,
+//i.e. $0 in eq(<User.password>,$0), using the predicates of the variables.
+func (r redactor) vars(vars map[string]string, preds map[string]Predicate) map[string]string {
+	var ret = make(map[string]string, len(vars))
+	for k, v := range vars {
+		if _, ok := r[preds[k]]; ok {
+			v = redacted
+		}
+		ret[k] = v
+	}
+	return ret
+}
+
+//json returns the mutation json with the values of redacted predicates masked.
+func (r redactor) json(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return redacted
+	}
+	b, err := json.Marshal(r.value(v))
+	if err != nil {
+		return redacted
+	}
+	return string(b)
+}
+
+func (r redactor) value(v interface{}) interface{} {
+	switch a := v.(type) {
+	case map[string]interface{}:
+		for k, val := range a {
+			//Language tagged values, i.e. User.name@en.
+			pred := k
+			if i := strings.IndexByte(k, '@'); i > 0 {
+				pred = k[:i]
+			}
+			if _, ok := r[Predicate(pred)]; ok {
+				a[k] = redacted
+				continue
+			}
+			a[k] = r.value(val)
+		}
+	case []interface{}:
+		for k, val := range a {
+			a[k] = r.value(val)
+		}
+	}
+	return v
+}
+
+//logMiddleware logs every request with its kind, names, duration, amount of variables
+//and result size. Failed requests are logged at error level and requests slower than
+//slow, if set, at warn level along with their redacted body.
+func logMiddleware(l Logger, slow time.Duration, r redactor) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*api.Response, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			dur := time.Since(start)
+			var size int
+			if resp != nil {
+				size = len(resp.Json)
+			}
+			var fields = []LogField{
+				{"kind", req.Kind},
+				{"names", strings.Join(req.Names, ",")},
+				{"duration", dur},
+				{"vars", len(req.Vars)},
+				{"mutations", len(req.Mutations)},
+				{"size", size},
+			}
+			level := LevelDebug
+			if slow > 0 && dur >= slow {
+				level = LevelWarn
+				if req.Query != "" {
+					fields = append(fields, LogField{"query", req.Query}, LogField{"variables", r.vars(req.Vars, req.Predicates)})
+				}
+				for k, v := range req.Mutations {
+					var key = fmt.Sprintf("mutation%d", k)
+					if len(v.SetJson) > 0 {
+						fields = append(fields, LogField{key + ".set", r.json(v.SetJson)})
+					}
+					if len(v.DeleteJson) > 0 {
+						fields = append(fields, LogField{key + ".delete", r.json(v.DeleteJson)})
+					}
+				}
+			}
+			if err != nil {
+				level = LevelError
+				fields = append(fields, LogField{"error", err})
+			}
+			l.Log(level, "dgraph "+string(req.Kind), fields...)
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package humus
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Math represents a math expression on value variables, i.e. x * 2 + z.
+//It is declared into a value variable using Mod.Math, generating y as math(x * 2 + z).
+//The expression is validated before use, only allowing value variables, numbers,
+//operators and the functions supported by Dgraph.
+type Math string
+
+//mathFunctions are the functions allowed in a math expression.
+var mathFunctions = map[string]struct{}{
+	"min":     {},
+	"max":     {},
+	"sqrt":    {},
+	"ln":      {},
+	"exp":     {},
+	"pow":     {},
+	"logbase": {},
+	"floor":   {},
+	"ceil":    {},
+	"since":   {},
+	"cond":    {},
+	"dot":     {},
+}
+
+func isMathOperator(b byte) bool {
+	switch b {
+	case '+', '-', '*', '/', '%', '<', '>', '=', '!', '(', ')', ',', ' ':
+		return true
+	}
+	return false
+}
+
+//variables validates the expression and returns the value variables used in it.
+func (m Math) variables() ([]Variable, error) {
+	var vars []Variable
+	s := string(m)
+	if strings.TrimSpace(s) == "" {
+		return nil, fmt.Errorf("empty math expression")
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case isMathOperator(c):
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+				i++
+			}
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_':
+			start := i
+			for i < len(s) && validVariableName(s[start:i+1]) {
+				i++
+			}
+			name := s[start:i]
+			if i < len(s) && s[i] == '(' {
+				if _, ok := mathFunctions[name]; !ok {
+					return nil, fmt.Errorf("invalid function %s in math expression", name)
+				}
+				continue
+			}
+			vars = append(vars, Variable(name))
+		default:
+			return nil, fmt.Errorf("invalid character %q in math expression", c)
+		}
+	}
+	return vars, nil
+}
+
+//mathValue represents a value variable declared using a math expression.
+type mathValue struct {
+	name Variable
+	expr Math
+}
+
+func (m mathValue) canApply(mt modifierSource) bool {
+	return true
+}
+
+func (m mathValue) apply(root *GeneratedQuery, meta FieldMeta, mt modifierSource, sb *strings.Builder) error {
+	if _, err := m.expr.variables(); err != nil {
+		return err
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(string(m.name))
+	sb.WriteString(" as math(")
+	sb.WriteString(string(m.expr))
+	sb.WriteString(") ")
+	return nil
+}
+
+func (m mathValue) priority() modifierType {
+	return modifierVariable
+}
+
+func (m mathValue) parenthesis() bool {
+	return false
+}
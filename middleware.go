@@ -0,0 +1,91 @@
+package humus
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dgraph-io/dgo/protos/api"
+)
+
+//RequestKind is the kind of call made to Dgraph.
+type RequestKind string
+
+const (
+	RequestQuery  RequestKind = "query"
+	RequestMutate RequestKind = "mutate"
+	RequestUpsert RequestKind = "upsert"
+)
+
+//Request is a single call to Dgraph from a Txn as passed through the middleware.
+//Changes to the request by a middleware are sent to Dgraph.
+type Request struct {
+	//Kind is the kind of call.
+	Kind RequestKind
+	//Names are the names of the query blocks.
+	Names []string
+	//Query is the rendered query of a query or upsert.
+	Query string
+	//Vars are the GraphQL variables of the query.
+	Vars map[string]string
+	//Predicates are the predicates the variables are compared to, keyed by
+	//variable, i.e. $0 in eq(User.email,$0). Only known for generated queries.
+	Predicates map[string]Predicate
+	//Mutations are the mutations of a mutate or upsert, holding the mutation json.
+	Mutations []*api.Mutation
+	//do performs the call to Dgraph at the end of the chain.
+	do Handler
+}
+
+//predicateQuery is a query which knows the predicates of its variables.
+type predicateQuery interface {
+	predicates() map[string]Predicate
+}
+
+//predicates returns the predicates of the variables of the query, if known.
+func predicates(q Query) map[string]Predicate {
+	if p, ok := q.(predicateQuery); ok {
+		return p.predicates()
+	}
+	return nil
+}
+
+//Handler performs the request, returning the response from Dgraph.
+type Handler func(ctx context.Context, req *Request) (*api.Response, error)
+
+//Middleware wraps a handler, i.e. to log, time or fail requests.
+//It calls next to continue the chain.
+type Middleware func(next Handler) Handler
+
+//Use adds middleware wrapping every query, mutation and upsert in transactions from
+//this DB. The first middleware added is the outermost. A middleware passing on another
+//request than the one it was called with should copy it, i.e. r := *req.
+func (d *DB) Use(m ...Middleware) {
+	d.middlewareMu.Lock()
+	defer d.middlewareMu.Unlock()
+	d.middleware = append(d.middleware, m...)
+	var h Handler = doRequest
+	for i := len(d.middleware) - 1; i >= 0; i-- {
+		h = d.middleware[i](h)
+	}
+	d.handler = h
+}
+
+//doRequest ends the chain by performing the call of the request.
+func doRequest(ctx context.Context, req *Request) (*api.Response, error) {
+	if req.do == nil {
+		return nil, errors.New("middleware passed on a request without its call")
+	}
+	return req.do(ctx, req)
+}
+
+//run runs the request through the middleware, ending with h.
+func (d *DB) run(ctx context.Context, req *Request, h Handler) (*api.Response, error) {
+	req.do = h
+	d.middlewareMu.RLock()
+	handler := d.handler
+	d.middlewareMu.RUnlock()
+	if handler == nil {
+		return h(ctx, req)
+	}
+	return handler(ctx, req)
+}
@@ -2,6 +2,7 @@ package humus
 
 import (
 	"errors"
+	"fmt"
 	"sort"
 	"strings"
 )
@@ -17,6 +18,19 @@ type mapElement struct {
 	f facet
 	g groupBy
 	q *GeneratedQuery
+	//The language fallback chain for the field at this path.
+	lang []Language
+	//The first conflict when applying modifiers. It is returned on Process.
+	err error
+}
+
+//fail stores the first error for this path. It always returns false
+//so it can be returned directly from a Mod.
+func (m *mapElement) fail(err error) bool {
+	if m.err == nil {
+		m.err = err
+	}
+	return false
 }
 
 type facetCreator mapElement
@@ -56,10 +70,21 @@ type Mod interface {
 		same syntax as a function.
 	*/
 	Filter(t FunctionType, variables ...interface{}) bool
+	/*
+		FilterTree creates a filter at this level from a filter expression tree
+		built using NewFilter, And, Or and Not. The tree is copied so it can
+		safely be reused.
+	*/
+	FilterTree(f *Filter) bool
 	/*
 		Sort applies a sorting at this level.
 	*/
 	Sort(t OrderType, p Predicate) bool
+	/*
+		SortVariable applies a sorting on a value variable at this level, i.e.
+		orderdesc: val(v).
+	*/
+	SortVariable(t OrderType, v Variable) bool
 	/*
 		Aggregate sets an aggregation at this level.
 	*/
@@ -77,6 +102,20 @@ type Mod interface {
 		can be useful for setting facet variables where name is omitted.
 	*/
 	Variable(name string, value string, isAlias bool) bool
+	/*
+		Value declares a value variable at this level, i.e. v as p.
+	*/
+	Value(v Variable, p Predicate) bool
+	/*
+		Math declares a value variable from a math expression at this level,
+		i.e. v as math(expr).
+	*/
+	Math(v Variable, expr Math) bool
+	/*
+		Language sets the language fallback chain for the field at this path,
+		overriding the language of the query.
+	*/
+	Language(langs ...Language) bool
 }
 
 type modifierType uint8
@@ -198,11 +237,19 @@ func (m modifierList) runFacet(q *GeneratedQuery, meta FieldMeta, sb *strings.Bu
 }
 
 func (m *modifierCreator) Paginate(t PaginationType, value int) bool {
+	for _, v := range m.m {
+		if p, ok := v.(pagination); ok && p.Type == t {
+			return (*mapElement)(m).fail(fmt.Errorf("pagination %s set twice", t))
+		}
+	}
 	m.m = append(m.m, pagination{Type: t, Value: value})
 	return true
 }
 
 func (m *modifierCreator) Variable(name string, value string, isAlias bool) bool {
+	if name != "" && !isAlias {
+		m.q.declare(Variable(name))
+	}
 	m.m = append(m.m, variable{
 		name:  name,
 		value: value,
@@ -211,23 +258,85 @@ func (m *modifierCreator) Variable(name string, value string, isAlias bool) bool
 	return true
 }
 
+func (m *modifierCreator) Value(v Variable, p Predicate) bool {
+	if !validVariableName(string(v)) {
+		return (*mapElement)(m).fail(fmt.Errorf("invalid value variable name %s", v))
+	}
+	m.q.declare(v)
+	m.m = append(m.m, variable{
+		name:  string(v),
+		value: string(p),
+	})
+	return true
+}
+
+func (m *modifierCreator) Math(v Variable, expr Math) bool {
+	if !validVariableName(string(v)) {
+		return (*mapElement)(m).fail(fmt.Errorf("invalid value variable name %s", v))
+	}
+	vars, err := expr.variables()
+	if err != nil {
+		return (*mapElement)(m).fail(err)
+	}
+	for _, val := range vars {
+		m.q.use(val)
+	}
+	m.q.declare(v)
+	m.m = append(m.m, mathValue{
+		name: v,
+		expr: expr,
+	})
+	return true
+}
+
 func (m *modifierCreator) Filter(t FunctionType, variables ...interface{}) bool {
-	var filter Filter
-	filter.typ = t
-	filter.variables = make([]graphVariable, len(variables))
-	for k, v := range variables {
-		val, typ := processInterface(v)
-		filter.variables[k] = graphVariable{
-			Value: val,
-			Type:  typ,
-		}
+	filter := NewFilter(t, variables...)
+	if err := filter.mapVariables(m.q); err != nil {
+		return (*mapElement)(m).fail(err)
 	}
-	filter.mapVariables(m.q)
-	m.m = append(m.m, &filter)
+	m.m = m.m.mergeFilter(filter)
 	return true
 }
 
+func (m *modifierCreator) FilterTree(f *Filter) bool {
+	if f == nil {
+		return (*mapElement)(m).fail(errors.New("nil filter tree"))
+	}
+	filter := f.copy()
+	filter.ignoreHeader = false
+	if err := filter.mapVariables(m.q); err != nil {
+		return (*mapElement)(m).fail(err)
+	}
+	m.m = m.m.mergeFilter(filter)
+	return true
+}
+
+//mergeFilter adds the filter to the list. Since only one filter is allowed per level
+//it is combined with an existing filter using AND.
+func (m modifierList) mergeFilter(f *Filter) modifierList {
+	for k, v := range m {
+		old, ok := v.(*Filter)
+		if !ok {
+			continue
+		}
+		if old.op == logicalAnd {
+			old.nodes = append(old.nodes, f)
+		} else {
+			m[k] = &Filter{
+				op:           logicalAnd,
+				nodes:        []*Filter{old, f},
+				ignoreHeader: old.ignoreHeader,
+			}
+		}
+		return m
+	}
+	return append(m, f)
+}
+
 func (m *modifierCreator) Sort(t OrderType, p Predicate) bool {
+	if m.m.hasOrdering(p) {
+		return (*mapElement)(m).fail(fmt.Errorf("sort on %s set twice", p))
+	}
 	m.m = append(m.m, Ordering{
 		Type:      t,
 		Predicate: p,
@@ -235,7 +344,29 @@ func (m *modifierCreator) Sort(t OrderType, p Predicate) bool {
 	return true
 }
 
+func (m *modifierCreator) SortVariable(t OrderType, v Variable) bool {
+	if !validVariableName(string(v)) {
+		return (*mapElement)(m).fail(fmt.Errorf("invalid value variable name %s", v))
+	}
+	m.q.use(v)
+	return m.Sort(t, Predicate("val("+string(v)+")"))
+}
+
+func (m *modifierCreator) Language(langs ...Language) bool {
+	if err := checkLanguages(langs); err != nil {
+		return (*mapElement)(m).fail(err)
+	}
+	if len(m.lang) != 0 {
+		return (*mapElement)(m).fail(errors.New("language set twice"))
+	}
+	m.lang = langs
+	return true
+}
+
 func (m *modifierCreator) Aggregate(t AggregateType, v string, alias string) bool {
+	if t != Count {
+		m.q.use(Variable(v))
+	}
 	m.m = append(m.m, aggregateValues{
 		Type:     t,
 		Alias:    alias,
@@ -266,6 +397,16 @@ func (m modifierList) hasModifier(mt modifierType) bool {
 	return false
 }
 
+//hasOrdering returns whether there is a sort on the predicate.
+func (m modifierList) hasOrdering(p Predicate) bool {
+	for _, v := range m {
+		if o, ok := v.(Ordering); ok && o.Predicate == p {
+			return true
+		}
+	}
+	return false
+}
+
 func (m modifierList) Less(i, j int) bool {
 	return m[i].priority() < m[j].priority()
 }
@@ -295,6 +436,13 @@ func (a aggregateValues) apply(root *GeneratedQuery, meta FieldMeta, mt modifier
 		sb.WriteString(a.Alias)
 		sb.WriteString(" : ")
 	}
+	//A value is simply written as val(v).
+	if a.Type == Val {
+		sb.WriteString("val(")
+		sb.WriteString(a.Variable)
+		sb.WriteString(") ")
+		return nil
+	}
 	sb.WriteString(string(a.Type))
 	isCount := a.Type == "count"
 	sb.WriteByte('(')
@@ -328,6 +476,9 @@ func (g *groupCreator) Paginate(t PaginationType, value int) bool {
 }
 
 func (g *groupCreator) Variable(name string, value string, isAlias bool) bool {
+	if name != "" && !isAlias {
+		g.q.declare(Variable(name))
+	}
 	g.g.m = append(g.g.m, variable{
 		name:  name,
 		value: value,
@@ -340,11 +491,42 @@ func (g *groupCreator) Filter(t FunctionType, variables ...interface{}) bool {
 	return false
 }
 
+func (g *groupCreator) FilterTree(f *Filter) bool {
+	return false
+}
+
 func (g *groupCreator) Sort(t OrderType, p Predicate) bool {
 	return false
 }
 
+func (g *groupCreator) Value(v Variable, p Predicate) bool {
+	if !validVariableName(string(v)) {
+		return (*mapElement)(g).fail(fmt.Errorf("invalid value variable name %s", v))
+	}
+	g.q.declare(v)
+	g.g.m = append(g.g.m, variable{
+		name:  string(v),
+		value: string(p),
+	})
+	return true
+}
+
+func (g *groupCreator) Math(v Variable, expr Math) bool {
+	return false
+}
+
+func (g *groupCreator) SortVariable(t OrderType, v Variable) bool {
+	return false
+}
+
+func (g *groupCreator) Language(langs ...Language) bool {
+	return false
+}
+
 func (g *groupCreator) Aggregate(t AggregateType, v string, alias string) bool {
+	if t != Count {
+		g.q.use(Variable(v))
+	}
 	g.g.m = append(g.g.m, aggregateValues{
 		Type:     t,
 		Alias:    alias,
@@ -0,0 +1,169 @@
+package humus
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//Paginator iterates over the results of a query in pages using cursor pagination,
+//i.e. first: N, after: uid, where after is the uid of the last node in the previous page.
+//Run it inside a read-only Txn, i.e. db.NewTxn(true), for consistent reads across pages.
+//
+//	p := humus.NewPaginator(txn, humus.NewQuery(EventFields).Function(humus.Type).Values("Event"), 100)
+//	var page []*Event
+//	for p.Next(ctx, &page) {
+//		...
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+type Paginator struct {
+	txn   *Txn
+	size  int
+	query string
+	vars  map[string]string
+	preds map[string]Predicate
+	names []string
+	//The GraphQL variable for the after uid.
+	after string
+	last  UID
+	done  bool
+	err   error
+}
+
+//paginationVariable is a pagination using a GraphQL variable as the value.
+type paginationVariable struct {
+	Type PaginationType
+	key  string
+}
+
+func (p paginationVariable) canApply(mt modifierSource) bool {
+	return true
+}
+
+func (p paginationVariable) parenthesis() bool {
+	return true
+}
+
+func (p paginationVariable) apply(root *GeneratedQuery, meta FieldMeta, mt modifierSource, sb *strings.Builder) error {
+	sb.WriteString(string(p.Type))
+	sb.WriteString(tokenColumn)
+	sb.WriteString(p.key)
+	return nil
+}
+
+func (p paginationVariable) priority() modifierType {
+	return modifierPagination
+}
+
+//NewPaginator creates a paginator over the query with pages of size nodes.
+//The query is compiled once and should not be processed or modified afterwards.
+//It may not have pagination or ordering at the root as pages are ordered by uid.
+func NewPaginator(txn *Txn, q *GeneratedQuery, size int) *Paginator {
+	p := &Paginator{txn: txn, size: size}
+	if size <= 0 {
+		p.err = errors.New("paginator requires a positive page size")
+		return p
+	}
+	if !q.single() {
+		p.err = errors.New("paginator requires a single query")
+		return p
+	}
+	q.At("", nil)
+	root := q.modifiers[""]
+	if root.m.hasModifier(modifierPagination) || root.m.hasModifier(modifierOrder) {
+		p.err = errors.New("paginator with pagination or ordering at the root")
+		return p
+	}
+	first := q.registerVariable(typeInt, strconv.Itoa(size), "")
+	p.after = q.registerVariable(typeString, "0x0", "")
+	root.m = append(root.m, paginationVariable{Type: CountFirst, key: first},
+		paginationVariable{Type: CountAfter, key: p.after})
+	p.query, p.err = q.Process()
+	p.vars = q.queryVars()
+	p.preds = q.predicates()
+	p.names = q.names()
+	return p
+}
+
+//Next fetches the next page into v, a pointer to a slice of nodes such as *[]*Event.
+//It returns false when there are no more pages, when ctx is done or on error, see Err.
+func (p *Paginator) Next(ctx context.Context, v interface{}) bool {
+	if p.err != nil || p.done {
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		p.err = err
+		return false
+	}
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Slice {
+		p.err = errors.New("paginator requires a pointer to a slice")
+		return false
+	}
+	//Reset the page as decoding appends to existing elements.
+	val.Elem().SetLen(0)
+	var vars = make(map[string]string, len(p.vars))
+	for k, v := range p.vars {
+		vars[k] = v
+	}
+	if p.last != "" {
+		vars[p.after] = string(p.last)
+	}
+	p.err = p.txn.Query(ctx, pageQuery{p: p, vars: vars}, v)
+	if p.err != nil {
+		return false
+	}
+	page := val.Elem()
+	if page.Len() < p.size {
+		p.done = true
+	}
+	if page.Len() == 0 {
+		return false
+	}
+	elem := page.Index(page.Len() - 1)
+	if elem.Kind() != reflect.Ptr {
+		elem = elem.Addr()
+	}
+	if elem.IsNil() {
+		p.err = errors.New("paginator got a nil node")
+		return false
+	}
+	last, ok := elem.Interface().(interface{ UID() UID })
+	if !ok {
+		p.err = errors.New("paginator requires a slice of nodes")
+		return false
+	}
+	p.last = last.UID()
+	return true
+}
+
+//Err returns the error that stopped the paginator, if any.
+func (p *Paginator) Err() error {
+	return p.err
+}
+
+//pageQuery is a single page of a paginator.
+type pageQuery struct {
+	p    *Paginator
+	vars map[string]string
+}
+
+func (q pageQuery) Process() (string, error) {
+	return q.p.query, nil
+}
+
+func (q pageQuery) queryVars() map[string]string {
+	return q.vars
+}
+
+func (q pageQuery) predicates() map[string]Predicate {
+	return q.p.preds
+}
+
+func (q pageQuery) names() []string {
+	return q.p.names
+}
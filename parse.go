@@ -16,29 +16,77 @@ var json = jsoniter.Config{
 	TagKey: "predicate",
 }.Froze()
 
+func init() {
+	json.RegisterExtension(&langExtension{})
+}
+
 //handleResponse takes the raw input from Dgraph and deserializes into the interfaces
 //as provided by inp given the query names. It will use easyjson if available,
 //otherwise defaults to standard json.
 func handleResponse(res []byte, inp []interface{}, names []string) error {
-	i := -1
+	//Dgraph leaves out empty blocks, so the keys are looked up by name rather than position.
+	index := make(map[string]int, len(names))
+	for k, v := range names {
+		index[v] = k
+	}
 	//This uses zero memory allocations to traverse the query tree.
 	//Since we do not want to deserialize the query root but rather the containing values
 	//traversing the query root with zero allocations is a large benefit, making jsonparser
 	//a very useful library here.
 	//Alternatively you can deserialize into an arbitrary object and use that but it is a lot less efficient.
 	return parse.ObjectEach(res, func(key []byte, value []byte, _ parse.ValueType, _ int) error {
-		i++
+		i, ok := index[string(key)]
+		if !ok {
+			return fmt.Errorf("unexpected key in response: %s", string(key))
+		}
 		//Skip empty return values.
 		if string(value) == "[]" {
 			return nil
 		}
-		if string(key) != names[i] {
-			return fmt.Errorf("mismatch between query name and key: %s : %s", string(key), names[i])
-		}
 		return singleResponse(value, inp[i])
 	})
 }
 
+//mapResponse returns the map if inp is a map keyed by strings, such as
+//map[string]interface{} or map[string]json.RawMessage, or a pointer to one.
+func mapResponse(inp interface{}) (reflect.Value, bool) {
+	val := reflect.ValueOf(inp)
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Map || val.Type().Key().Kind() != reflect.String {
+		return reflect.Value{}, false
+	}
+	if val.IsNil() {
+		if !val.CanSet() {
+			return reflect.Value{}, false
+		}
+		val.Set(reflect.MakeMap(val.Type()))
+	}
+	return val, true
+}
+
+//handleMapResponse deserializes every query in res into the map m
+//keyed by the query name.
+func handleMapResponse(res []byte, m reflect.Value) error {
+	elem := m.Type().Elem()
+	key := m.Type().Key()
+	return parse.ObjectEach(res, func(k []byte, value []byte, _ parse.ValueType, _ int) error {
+		ptr := reflect.New(elem)
+		if err := json.Unmarshal(value, ptr.Interface()); err != nil {
+			return err
+		}
+		m.SetMapIndex(reflect.ValueOf(string(k)).Convert(key), ptr.Elem())
+		return nil
+	})
+}
+
+//listResponse is implemented by results decoding the whole result list,
+//such as AggregateResult where the values are split over several objects.
+type listResponse interface {
+	listResponse()
+}
+
 //singleResponse deserializes the json in value into the pointer value
 //represented by inp.
 func singleResponse(value []byte, inp interface{}) error {
@@ -55,7 +103,7 @@ func singleResponse(value []byte, inp interface{}) error {
 	isArray := kind == reflect.Slice || kind == reflect.Array
 	//No need for massive reflect to check if it is an array
 	if value[0] == '[' {
-		if !isArray {
+		if _, ok := inp.(listResponse); !isArray && !ok {
 			value = value[1 : len(value)-1]
 		}
 	}
@@ -0,0 +1,134 @@
+package humus
+
+import (
+	"errors"
+	"fmt"
+)
+
+//PreparedQuery is a compiled query template where only the values of the GraphQL
+//variables are supplied on execution. It is immutable and safe for concurrent use.
+//Create it using GeneratedQuery.Prepare.
+type PreparedQuery struct {
+	query  string
+	params []varType
+	//keys holds the GraphQL variable for each value to bind.
+	keys []string
+	name []string
+	//The predicates of the variables.
+	preds map[string]Predicate
+}
+
+//Prepare compiles the query once into a template. The values supplied to the query,
+//including uids in the root function, are bound as GraphQL variables using Bind. Values are bound
+//in the order of the root function followed by the modifiers in the order they were added.
+//It should be called at init on a query that has not been processed.
+func (q *GeneratedQuery) Prepare() (*PreparedQuery, error) {
+	if !q.single() {
+		return nil, errors.New("prepare requires a single query")
+	}
+	if q.processed {
+		return nil, errors.New("prepare on an already processed query")
+	}
+	q.prepared = true
+	str, err := q.create(nil)
+	if err != nil {
+		return nil, err
+	}
+	p := &PreparedQuery{
+		query:  str,
+		params: make([]varType, 0, len(q.params)),
+		keys:   make([]string, 0, len(q.params)),
+		name:   q.names(),
+		preds:  q.predicates(),
+	}
+	//The root function is bound first, followed by the remaining variables in the
+	//order they were registered, that is the order the modifiers were added.
+	var root = make(map[string]struct{})
+	for _, v := range q.function.variables {
+		for _, par := range q.params {
+			if par.key == v.Value {
+				root[par.key] = struct{}{}
+				p.add(par)
+			}
+		}
+	}
+	for _, v := range q.params {
+		if _, ok := root[v.key]; !ok {
+			p.add(v)
+		}
+	}
+	return p, nil
+}
+
+//param is a GraphQL variable to bind a value to.
+type param struct {
+	key string
+	typ varType
+	//The predicate the value is compared to, if any.
+	pred Predicate
+}
+
+func (p *PreparedQuery) add(par param) {
+	p.params = append(p.params, par.typ)
+	p.keys = append(p.keys, par.key)
+}
+
+//Params returns the amount of values to bind.
+func (p *PreparedQuery) Params() int {
+	return len(p.params)
+}
+
+//Bind returns a query with the values bound to the GraphQL variables of the template.
+//The values must match the amount and types of the values the template was prepared with.
+//An error is returned when processing the returned query.
+func (p *PreparedQuery) Bind(values ...interface{}) Query {
+	b := boundQuery{p: p}
+	if len(values) != len(p.params) {
+		b.err = fmt.Errorf("prepared query expects %d values, got %d", len(p.params), len(values))
+		return b
+	}
+	b.vars = make(map[string]string, len(values))
+	for k, v := range values {
+		val, typ := processInterface(v)
+		//Integers are valid floats.
+		if typ == typeInt && p.params[k] == typeFloat {
+			typ = typeFloat
+		}
+		if typ != p.params[k] {
+			b.err = fmt.Errorf("invalid type %s for value %d in prepared query, expected %s", typ, k, p.params[k])
+			return b
+		}
+		if typ == typeUid && !validUid(UID(val)) {
+			b.err = fmt.Errorf("invalid uid %s in prepared query", val)
+			return b
+		}
+		b.vars[p.keys[k]] = val
+	}
+	return b
+}
+
+//boundQuery is a prepared query with its values bound.
+type boundQuery struct {
+	p    *PreparedQuery
+	vars map[string]string
+	err  error
+}
+
+func (b boundQuery) Process() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+	return b.p.query, nil
+}
+
+func (b boundQuery) queryVars() map[string]string {
+	return b.vars
+}
+
+func (b boundQuery) predicates() map[string]Predicate {
+	return b.p.preds
+}
+
+func (b boundQuery) names() []string {
+	return b.p.name
+}
@@ -2,6 +2,8 @@ package humus
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 )
@@ -56,10 +58,26 @@ const (
 
 //Queries represents multiple queries at once.
 type Queries struct {
-	q          []*GeneratedQuery
+	q          []queryBlock
 	varCounter func() int
 	currentVar int
 	vars       map[string]string
+	//The schema to validate queries against, if any.
+	schema SchemaList
+}
+
+//queryBlock is a single block in a multiple query, either
+//a GeneratedQuery or a StaticBlock.
+type queryBlock interface {
+	//mapBlock maps the GraphQL variables of the block.
+	mapBlock()
+	//variables returns the GraphQL variable declarations of the block, i.e. $0:string.
+	variables() string
+	//declares returns the value variables declared in the block.
+	declares() []Variable
+	checkVariables(declared map[Variable]struct{}) error
+	names() []string
+	create(sb *strings.Builder) (string, error)
 }
 
 //Satisfy the Query interface.
@@ -68,20 +86,9 @@ func (q *Queries) Process() (string, error) {
 }
 
 func (q *Queries) names() []string {
-	c := len(q.q)
-	for _, v := range q.q {
-		if v.variable.varQuery {
-			c--
-		}
-	}
-	ret := make([]string, c)
-	count := 0
+	ret := make([]string, 0, len(q.q))
 	for _, v := range q.q {
-		if v.variable.varQuery {
-			continue
-		}
-		ret[count] = "q" + strconv.Itoa(v.index)
-		count++
+		ret = append(ret, v.names()...)
 	}
 	return ret
 }
@@ -101,37 +108,68 @@ func (q *Queries) NewQuery(f Fields) *GeneratedQuery {
 func (q *Queries) create() (string, error) {
 	var final strings.Builder
 	final.Grow(512)
-	//The query variable information. Named per default.
-	//TODO: Right now it breaks if no queries have vars.
-	final.WriteString("query t")
-	//The global variable counter. It exists in a closure, it's just easy.
-	final.WriteString("(")
-	for k, qu := range q.q {
-		qu.mapVariables(qu)
-		str := qu.variables()
-		if str == "" {
-			continue
+	var vars = make([]string, 0, len(q.q))
+	//Variables have to be declared in the same or a previous query.
+	var declared = make(map[Variable]struct{})
+	var named = make(map[string]struct{})
+	//The types of the declared GraphQL variables, as static blocks may share keys.
+	var types = make(map[string]string)
+	count := 0
+	for _, qu := range q.q {
+		qu.mapBlock()
+		if str := qu.variables(); str != "" {
+			for _, decl := range strings.Split(str, ",") {
+				i := strings.IndexByte(decl, ':')
+				key, typ := decl[:i], decl[i+1:]
+				if old, ok := types[key]; ok {
+					if old != typ {
+						return "", fmt.Errorf("variable %s declared as both %s and %s", key, old, typ)
+					}
+					continue
+				}
+				types[key] = typ
+				vars = append(vars, decl)
+			}
 		}
-		//TODO: Make it more like a strings.Join to avoid all these error-prone additions.
-		if len(q.q) > 1 && k > 0 {
-			final.WriteByte(',')
+		if g, ok := qu.(*GeneratedQuery); ok {
+			if !g.variable.varQuery {
+				g.index = count
+				count++
+			}
+			if q.schema != nil {
+				if err := g.validate(q.schema); err != nil {
+					return "", err
+				}
+			}
+		}
+		for _, name := range qu.names() {
+			if _, ok := named[name]; ok {
+				return "", fmt.Errorf("duplicate query name %s", name)
+			}
+			named[name] = struct{}{}
+		}
+		for _, v := range qu.declares() {
+			declared[v] = struct{}{}
+		}
+		if err := qu.checkVariables(declared); err != nil {
+			return "", err
 		}
-		final.WriteString(str)
 	}
-	final.WriteByte(')')
-	count := 0
+	//The query variable information. Named per default.
+	if len(vars) == 0 {
+		final.WriteString("query{")
+	} else {
+		final.WriteString("query t(")
+		final.WriteString(strings.Join(vars, ","))
+		final.WriteString("){")
+	}
 	for _, qu := range q.q {
-		final.WriteByte('{')
-		if !qu.variable.varQuery {
-			qu.index = count
-			count++
-		}
 		_, err := qu.create(&final)
 		if err != nil {
 			return "", err
 		}
-		final.WriteByte('}')
 	}
+	final.WriteByte('}')
 	return final.String(), nil
 }
 
@@ -139,6 +177,18 @@ func (q *Queries) queryVars() map[string]string {
 	return q.vars
 }
 
+func (q *Queries) predicates() map[string]Predicate {
+	var ret = make(map[string]Predicate)
+	for _, v := range q.q {
+		if g, ok := v.(*GeneratedQuery); ok {
+			for key, pred := range g.predicates() {
+				ret[key] = pred
+			}
+		}
+	}
+	return ret
+}
+
 //GeneratedQuery is the root object of queries that are constructed.
 //It is constructed using a set of Fields that are either autogenerated or
 //manually specified. From its list of modifiers(orderby, pagination etc)
@@ -154,8 +204,8 @@ type GeneratedQuery struct {
 	directives []Directive
 	//The list of fields used in this query.
 	fields Fields
-	//The overall language for this query.
-	language Language
+	//The overall language fallback chain for this query.
+	languages []Language
 	//List of modifiers, i.e. order, pagination etc.
 	modifiers map[Predicate]*mapElement
 	//Map for dealing with GraphQL variables. It is inherited in multi-query layout.
@@ -167,13 +217,33 @@ type GeneratedQuery struct {
 	varCounter int
 	//For multiple queries. Used to keep track of the query name.
 	index int
+	//The name of the query block. If empty it is named from the index.
+	name string
 	//top level variable name
 	variable struct {
 		varName  string
 		varQuery bool
 	}
-	//Whether to allow untagged language.
-	strictLanguage bool
+	//The @recurse directive for this query.
+	recurse recurse
+	//The shortest path block this query fetches the nodes for.
+	shortest *ShortestQuery
+	//The aggregates over the nodes of this query.
+	aggregate *AggregateQuery
+	//Variables declared and used in this query.
+	declared []Variable
+	used     []Variable
+	//The schema to validate the query against, if any.
+	schema SchemaList
+	//The flat struct to normalize the results into along with its predicates.
+	normalize  reflect.Type
+	normalized map[Predicate]struct{}
+	//Whether the query is compiled using Prepare.
+	prepared bool
+	//Whether the query has been processed as a single query.
+	processed bool
+	//The GraphQL variables in order of registration.
+	params []param
 }
 
 //NewQuery returns a new singular generation query for use
@@ -190,7 +260,7 @@ func NewQuery(f Fields) *GeneratedQuery {
 //multiple queries at once.
 func NewQueries() *Queries {
 	qu := new(Queries)
-	qu.q = make([]*GeneratedQuery, 0, 2)
+	qu.q = make([]queryBlock, 0, 2)
 	qu.currentVar = -1
 	qu.varCounter = func() int {
 		qu.currentVar++
@@ -220,13 +290,51 @@ func (q *GeneratedQuery) single() bool {
 
 }
 func (q *GeneratedQuery) names() []string {
+	//The nodes of an aggregate are fetched in a var block.
+	if q.aggregate != nil {
+		return []string{q.blockName()}
+	}
 	if q.variable.varQuery {
 		return nil
 	}
-	if q.single() {
+	if q.shortest != nil {
+		return []string{q.shortest.resultName(), q.blockName()}
+	}
+	if q.single() && q.name == "" {
 		return defaultName
 	}
-	return []string{"q" + strconv.Itoa(q.index)}
+	return []string{q.blockName()}
+}
+
+//blockName returns the name of this query block, i.e. the key
+//in the result. Unless named it defaults to q + index.
+func (q *GeneratedQuery) blockName() string {
+	if q.name != "" {
+		return q.name
+	}
+	return "q" + strconv.Itoa(q.index)
+}
+
+//reservedName returns whether the name is of the form q + index, which
+//is used for blocks without a name.
+func reservedName(name string) bool {
+	if len(name) < 2 || name[0] != 'q' {
+		return false
+	}
+	for _, v := range name[1:] {
+		if v < '0' || v > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+//Name sets the name of this query block. The result for this query is returned
+//under this name, which allows decoding into a map keyed by the block name using QueryMap.
+//Names of the form q + index, i.e. q1, are reserved for blocks without a name.
+func (q *GeneratedQuery) Name(name string) *GeneratedQuery {
+	q.name = name
+	return q
 }
 
 func (q *GeneratedQuery) create(sb *strings.Builder) (string, error) {
@@ -235,16 +343,49 @@ func (q *GeneratedQuery) create(sb *strings.Builder) (string, error) {
 	//variables beforehand as it is otherwise calculated in the Queries calculation in Queries.create()
 	if sb == nil {
 		sb = new(strings.Builder)
-		q.mapVariables(q)
+		if err := q.mapVariables(q); err != nil {
+			return "", err
+		}
+		q.processed = true
 		sb.Grow(256)
 	}
 	if err := q.function.check(q); err != nil {
 		return "", err
 	}
+	if q.schema != nil {
+		if err := q.validate(q.schema); err != nil {
+			return "", err
+		}
+	}
+	if err := checkLanguages(q.languages); err != nil {
+		return "", err
+	}
+	if q.normalize != nil {
+		preds, err := normalizePredicates(q.normalize)
+		if err != nil {
+			return "", err
+		}
+		if err := checkNormalize(q.fields, preds); err != nil {
+			return "", err
+		}
+		q.normalized = preds
+	}
+	//Return any conflict from applying modifiers.
+	for _, v := range q.modifiers {
+		if v.err != nil {
+			return "", v.err
+		}
+	}
+	//Variables in multiple queries are checked in Queries.create.
+	if q.single() {
+		if err := q.checkVariables(nil); err != nil {
+			return "", err
+		}
+	}
 	//Top level modifiers.
 	val, ok := q.modifiers[""]
 	//Single query.
-	if q.single() && q.variable.varQuery {
+	if q.single() && q.variable.varQuery && q.aggregate == nil {
 		return "", errors.New("singular query with var set is invalid")
 	}
 	if q.single() {
@@ -257,6 +398,12 @@ func (q *GeneratedQuery) create(sb *strings.Builder) (string, error) {
 			sb.WriteString("){")
 		}
 	}
+	//The shortest path block is written before the block fetching its nodes.
+	if q.shortest != nil {
+		if err := q.shortest.create(sb); err != nil {
+			return "", err
+		}
+	}
 	//Write query header.
 	if q.variable.varQuery {
 		if q.variable.varName != "" {
@@ -265,12 +412,10 @@ func (q *GeneratedQuery) create(sb *strings.Builder) (string, error) {
 		}
 		sb.WriteString("var")
 	} else {
-		sb.WriteByte('q')
-		if q.index != 0 {
-			sb.WriteString(strconv.Itoa(q.index))
-		} else {
-			sb.WriteByte('0')
+		if q.name != "" && (!validVariableName(q.name) || reservedName(q.name)) {
+			return "", fmt.Errorf("invalid query name %s", q.name)
 		}
+		sb.WriteString(q.blockName())
 	}
 	sb.WriteString(tokenLP + "func" + tokenColumn + tokenSpace)
 	err := q.function.create(q, sb)
@@ -291,15 +436,39 @@ func (q *GeneratedQuery) create(sb *strings.Builder) (string, error) {
 		sb.WriteByte('@')
 		sb.WriteString(string(v))
 	}
+	if q.recurse.active {
+		if err := q.recurse.create(sb); err != nil {
+			return "", err
+		}
+	}
+	//A group by at the root replaces the fields of the query.
+	if ok && val.g.p != "" {
+		if err := val.g.apply(q, 0, modifierField, sb); err != nil {
+			return "", err
+		}
+		if q.single() {
+			sb.WriteByte('}')
+		}
+		return sb.String(), nil
+	}
 	sb.WriteByte('{')
 	var parentBuf = make([]byte, 0, 64)
 	for _, field := range q.fields.Get() {
-		if len(field.Name) > 64 {
+		if q.recurse.active {
+			//All fields apply at every level in a recurse query.
+			err := field.createRecurse(q, sb)
+			if err != nil {
+				return "", err
+			}
+			continue
+		}
+		path := field.path()
+		if len(path) > 64 {
 			//This code should pretty much never execute as a predicate is rarely this large.
-			parentBuf = make([]byte, 2*len(field.Name))
+			parentBuf = make([]byte, 2*len(path))
 		}
-		parentBuf = parentBuf[:len(field.Name)]
-		copy(parentBuf, field.Name)
+		parentBuf = parentBuf[:len(path)]
+		copy(parentBuf, path)
 		//parentBuf = append(parentBuf, field.Name...)
 		err := field.create(q, parentBuf, sb)
 		if err != nil {
@@ -314,6 +483,12 @@ func (q *GeneratedQuery) create(sb *strings.Builder) (string, error) {
 	}
 	//Add default uid to top level field and close query.
 	sb.WriteString(" uid" + tokenRB)
+	//The aggregate block is written after the var block of its nodes.
+	if q.aggregate != nil {
+		if err := q.aggregate.create(sb); err != nil {
+			return "", err
+		}
+	}
 	if q.single() {
 		sb.WriteByte('}')
 	}
@@ -324,6 +499,16 @@ func (q *GeneratedQuery) queryVars() map[string]string {
 	return q.varMap
 }
 
+func (q *GeneratedQuery) predicates() map[string]Predicate {
+	var ret = make(map[string]Predicate)
+	for _, v := range q.params {
+		if v.pred != "" {
+			ret[v.key] = v.pred
+		}
+	}
+	return ret
+}
+
 //Directive adds a top level directive.
 func (q *GeneratedQuery) Directive(dir Directive) *GeneratedQuery {
 	for _, v := range q.directives {
@@ -335,6 +520,19 @@ func (q *GeneratedQuery) Directive(dir Directive) *GeneratedQuery {
 	return q
 }
 
+//Recurse sets the query as a recurse query, @recurse(depth: depth, loop: loop).
+//In a recurse query the fields are applied at every level, meaning edges are
+//written without their sub fields. Modifiers at the path of an edge are applied at every level.
+//A depth of zero or less omits the depth, which is only valid if loop is false.
+func (q *GeneratedQuery) Recurse(depth int, loop bool) *GeneratedQuery {
+	q.recurse = recurse{
+		active: true,
+		depth:  depth,
+		loop:   loop,
+	}
+	return q
+}
+
 /*
 At allows you to run modifiers at a path. Modifiers include
 pagination, sorting, filters among others.
@@ -377,6 +575,7 @@ func (q *GeneratedQuery) Facets(path Predicate, op Operation) *GeneratedQuery {
 /*
 GroupBy allows you to groupBy at a leaf field. Using op specify a list of variables and operations
 to be written as aggregation at this level. onWhich specifies what predicate to actually group on.
+A path of "" groups the nodes at the root. The result is decoded using Groups.
 */
 func (q *GeneratedQuery) GroupBy(path Predicate, onWhich Predicate, op Operation) *GeneratedQuery {
 	val, ok := q.modifiers[path]
@@ -386,6 +585,10 @@ func (q *GeneratedQuery) GroupBy(path Predicate, onWhich Predicate, op Operation
 		q.modifiers[path] = val
 	}
 	var g = (*groupCreator)(val)
+	if g.g.p != "" && g.g.p != onWhich {
+		val.fail(fmt.Errorf("groupby at %s set twice", path))
+		return q
+	}
 	if op != nil {
 		op(g)
 	}
@@ -396,11 +599,33 @@ func (q *GeneratedQuery) GroupBy(path Predicate, onWhich Predicate, op Operation
 //Language sets the language for the query to apply to all fields.
 //If strict do not allow untagged language.
 func (q *GeneratedQuery) Language(l Language, strict bool) *GeneratedQuery {
-	q.language = l
-	q.strictLanguage = strict
+	q.languages = q.languages[:0]
+	if l != LanguageNone {
+		q.languages = append(q.languages, l)
+	}
+	if !strict {
+		q.languages = append(q.languages, LanguageUntagged)
+	}
+	return q
+}
+
+//Languages sets the language fallback chain for all fields, i.e. sv, en, LanguageUntagged
+//generating name@sv:en:. It can be overridden for a field using Mod.Language.
+func (q *GeneratedQuery) Languages(langs ...Language) *GeneratedQuery {
+	q.languages = append(q.languages[:0], langs...)
 	return q
 }
 
+//mapBlock maps the GraphQL variables of the query in a multiple query.
+//Invalid values in the root function are reported when checking the function on create.
+func (q *GeneratedQuery) mapBlock() {
+	_ = q.mapVariables(q)
+}
+
+func (q *GeneratedQuery) declares() []Variable {
+	return q.declared
+}
+
 //Returns all the query variables for this query in create form.
 func (q *GeneratedQuery) variables() string {
 	return q.varBuilder.String()
@@ -411,10 +636,15 @@ func (q *GeneratedQuery) variables() string {
 func (q *GeneratedQuery) Var(name string) *GeneratedQuery {
 	q.variable.varQuery = true
 	q.variable.varName = name
+	if name != "" {
+		q.declare(Variable(name))
+	}
 	return q
 }
 
-func (q *GeneratedQuery) registerVariable(typ varType, value string) string {
+//registerVariable registers the value as a GraphQL variable, recording the predicate
+//it is compared to, if any, for redacting it in logs.
+func (q *GeneratedQuery) registerVariable(typ varType, value string, pred Predicate) string {
 	if q.varBuilder.Len() != 0 {
 		q.varBuilder.WriteByte(',')
 	} else {
@@ -430,8 +660,9 @@ func (q *GeneratedQuery) registerVariable(typ varType, value string) string {
 	key := "$" + strconv.Itoa(val)
 	q.varBuilder.WriteString(key)
 	q.varBuilder.WriteByte(':')
-	q.varBuilder.WriteString(string(typ))
+	q.varBuilder.WriteString(string(typ.graphType()))
 	q.varMap[key] = value
+	q.params = append(q.params, param{key: key, typ: typ, pred: pred})
 	return key
 }
 
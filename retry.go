@@ -0,0 +1,95 @@
+package humus
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/dgraph-io/dgo"
+	errors2 "github.com/pkg/errors"
+)
+
+//TxnOptions configures the retries of RunInTxn. The zero value uses the defaults.
+type TxnOptions struct {
+	//MaxAttempts is the maximum amount of times the function is run. Defaults to 3.
+	MaxAttempts int
+	//Backoff is the delay before the first retry, doubled on every retry. Defaults to 10ms.
+	Backoff time.Duration
+	//MaxBackoff caps the delay between retries. Defaults to one second.
+	MaxBackoff time.Duration
+	//Jitter adds a random delay of up to the fraction of the delay, i.e. 0.5 adds up to 50%.
+	Jitter float64
+}
+
+//Default values for TxnOptions.
+const (
+	defaultMaxAttempts = 3
+	defaultBackoff     = 10 * time.Millisecond
+	defaultMaxBackoff  = time.Second
+)
+
+//delay returns the delay before the retry following the attempt, starting at one.
+func (o *TxnOptions) delay(attempt int) time.Duration {
+	d := o.Backoff
+	for i := 1; i < attempt && d < o.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > o.MaxBackoff {
+		d = o.MaxBackoff
+	}
+	if o.Jitter > 0 {
+		d += time.Duration(rand.Float64() * o.Jitter * float64(d))
+	}
+	return d
+}
+
+//IsAborted returns whether the error is caused by an aborted transaction,
+//i.e. due to a conflict with a concurrent transaction.
+func IsAborted(err error) bool {
+	return err != nil && errors2.Cause(err) == dgo.ErrAborted
+}
+
+//RunInTxn runs f in a new transaction and commits it. If the transaction is aborted,
+//either from f or on commit, f is run again in a new transaction after a backoff.
+//As such f should not have side effects outside the transaction. Errors from f are
+//returned as is and opts may be nil to use the defaults.
+func (d *DB) RunInTxn(ctx context.Context, f func(*Txn) error, opts *TxnOptions) error {
+	var o TxnOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultMaxAttempts
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = defaultBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = defaultMaxBackoff
+	}
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		txn := d.NewTxn(false)
+		err := f(txn)
+		if err == nil {
+			err = txn.Commit(ctx)
+		}
+		//Discarding a committed transaction is a no-op.
+		_ = txn.Discard(context.Background())
+		if !IsAborted(err) {
+			return err
+		}
+		if attempt >= o.MaxAttempts {
+			return errors2.Wrapf(err, "transaction aborted %d times", attempt)
+		}
+		timer := time.NewTimer(o.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package humus
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Vliro/humus/parse"
+	jsoniter "github.com/json-iterator/go"
+)
+
+//pathName is the key Dgraph returns the paths of all shortest blocks in.
+const pathName = "_path_"
+
+//ShortestQuery represents a k-shortest path query. It generates a shortest block
+//of the form path as shortest(from: A, to: B, ...){edges} along with
+//a block fetching the nodes of the path using the fields supplied.
+//Deserialize it using a []Path for the paths followed by a slice for the nodes.
+//In Queries every shortest block decodes only its own paths.
+type ShortestQuery struct {
+	from      UID
+	to        UID
+	numPaths  int
+	depth     int
+	minWeight float64
+	maxWeight float64
+	weighted  bool
+	edges     []shortestEdge
+	//The name of the value variable holding the path.
+	varName string
+	//The block fetching the nodes in the path.
+	q *GeneratedQuery
+}
+
+//shortestEdge represents an edge to traverse in a shortest query.
+//If facet is set the edge is weighted by the facet.
+type shortestEdge struct {
+	pred  Predicate
+	facet string
+}
+
+//NewShortestQuery creates a shortest path query from the node from to the node to.
+//The nodes in the path are fetched using the fields.
+func NewShortestQuery(from, to UID, fields Fields) *ShortestQuery {
+	return newShortest(NewQuery(fields), from, to, "path")
+}
+
+//Shortest adds a shortest path query to the list of queries.
+//The nodes in the path are fetched using the fields.
+func (q *Queries) Shortest(from, to UID, fields Fields) *ShortestQuery {
+	return newShortest(q.NewQuery(fields), from, to, "path"+strconv.Itoa(len(q.q)))
+}
+
+func newShortest(q *GeneratedQuery, from, to UID, name string) *ShortestQuery {
+	s := &ShortestQuery{
+		from:    from,
+		to:      to,
+		varName: name,
+		q:       q,
+	}
+	q.Function(FunctionUid).Values(Variable(name))
+	q.declare(Variable(name))
+	q.shortest = s
+	return s
+}
+
+//Edge adds an edge to traverse in the shortest path.
+func (s *ShortestQuery) Edge(p Predicate) *ShortestQuery {
+	s.edges = append(s.edges, shortestEdge{pred: p})
+	return s
+}
+
+//WeightedEdge adds an edge to traverse where the facet is used as the weight of the edge.
+func (s *ShortestQuery) WeightedEdge(p Predicate, facet string) *ShortestQuery {
+	s.edges = append(s.edges, shortestEdge{pred: p, facet: facet})
+	return s
+}
+
+//NumPaths sets the amount of paths to return, i.e. the k in k-shortest.
+func (s *ShortestQuery) NumPaths(n int) *ShortestQuery {
+	s.numPaths = n
+	return s
+}
+
+//Depth sets the maximum depth of the paths.
+func (s *ShortestQuery) Depth(n int) *ShortestQuery {
+	s.depth = n
+	return s
+}
+
+//Weight sets the minimum and maximum weight of the paths.
+func (s *ShortestQuery) Weight(min, max float64) *ShortestQuery {
+	s.minWeight = min
+	s.maxWeight = max
+	s.weighted = true
+	return s
+}
+
+//At applies modifiers at the path on the block fetching the nodes.
+//See GeneratedQuery.At.
+func (s *ShortestQuery) At(path Predicate, op Operation) *ShortestQuery {
+	s.q.At(path, op)
+	return s
+}
+
+//Language sets the language for the block fetching the nodes.
+func (s *ShortestQuery) Language(l Language, strict bool) *ShortestQuery {
+	s.q.Language(l, strict)
+	return s
+}
+
+//Process satisfies the Query interface.
+func (s *ShortestQuery) Process() (string, error) {
+	return s.q.Process()
+}
+
+func (s *ShortestQuery) queryVars() map[string]string {
+	return s.q.queryVars()
+}
+
+func (s *ShortestQuery) predicates() map[string]Predicate {
+	return s.q.predicates()
+}
+
+func (s *ShortestQuery) names() []string {
+	return s.q.names()
+}
+
+//resultName is the key the paths of this block are decoded from, i.e. _path_
+//for a single shortest query and _path0_ in Queries. See splitPaths.
+func (s *ShortestQuery) resultName() string {
+	return "_" + s.varName + "_"
+}
+
+//shortestBlocks returns the shortest blocks of the query.
+func shortestBlocks(q Query) []*ShortestQuery {
+	switch a := q.(type) {
+	case *ShortestQuery:
+		return []*ShortestQuery{a}
+	case *GeneratedQuery:
+		if a.shortest != nil {
+			return []*ShortestQuery{a.shortest}
+		}
+	case *Queries:
+		var ret []*ShortestQuery
+		for _, v := range a.q {
+			if g, ok := v.(*GeneratedQuery); ok && g.shortest != nil {
+				ret = append(ret, g.shortest)
+			}
+		}
+		return ret
+	}
+	return nil
+}
+
+//splitPaths rewrites the response such that the paths of every shortest block are
+//written under its own result name before the block fetching its nodes. Dgraph returns
+//the paths of all blocks under _path_, which are matched to a block by their first and last uid.
+func splitPaths(res []byte, blocks []*ShortestQuery) ([]byte, error) {
+	if len(blocks) == 0 {
+		return res, nil
+	}
+	var paths = make([][][]byte, len(blocks))
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	err := parse.ObjectEach(res, func(key []byte, value []byte, _ parse.ValueType, _ int) error {
+		if string(key) != pathName {
+			return nil
+		}
+		var list []jsoniter.RawMessage
+		if err := json.Unmarshal(value, &list); err != nil {
+			return err
+		}
+		for _, v := range list {
+			var p Path
+			if err := p.UnmarshalJSON(v); err != nil {
+				return err
+			}
+			for k, b := range blocks {
+				if len(p.Uids) > 0 && p.Uids[0] == b.from && p.Uids[len(p.Uids)-1] == b.to {
+					paths[k] = append(paths[k], v)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	write := func(key string, value []byte) {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('"')
+		buf.WriteString(key)
+		buf.WriteString(`":`)
+		buf.Write(value)
+	}
+	err = parse.ObjectEach(res, func(key []byte, value []byte, typ parse.ValueType, _ int) error {
+		if string(key) == pathName {
+			return nil
+		}
+		for k, b := range blocks {
+			if b.q.blockName() == string(key) {
+				write(b.resultName(), append(append([]byte{'['}, bytes.Join(paths[k], []byte{','})...), ']'))
+			}
+		}
+		if typ == parse.String {
+			value = append(append([]byte{'"'}, value...), '"')
+		}
+		write(string(key), value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+//validUid returns whether the uid is of the form 0xvalue.
+func validUid(u UID) bool {
+	if len(u) < 3 || len(u) > 18 || u[:2] != "0x" {
+		return false
+	}
+	_, err := strconv.ParseUint(string(u[2:]), 16, 64)
+	return err == nil
+}
+
+//create writes the shortest block. The uids are written as is
+//since they are validated.
+func (s *ShortestQuery) create(sb *strings.Builder) error {
+	if !validUid(s.from) || !validUid(s.to) {
+		return errors.New("invalid uid in shortest query")
+	}
+	if len(s.edges) == 0 {
+		return errors.New("missing edges in shortest query")
+	}
+	sb.WriteString(s.varName)
+	sb.WriteString(" as shortest(from: ")
+	sb.WriteString(string(s.from))
+	sb.WriteString(", to: ")
+	sb.WriteString(string(s.to))
+	if s.numPaths > 0 {
+		sb.WriteString(", numpaths: ")
+		sb.WriteString(strconv.Itoa(s.numPaths))
+	}
+	if s.depth > 0 {
+		sb.WriteString(", depth: ")
+		sb.WriteString(strconv.Itoa(s.depth))
+	}
+	if s.weighted {
+		sb.WriteString(", minweight: ")
+		sb.WriteString(strconv.FormatFloat(s.minWeight, 'f', -1, 64))
+		sb.WriteString(", maxweight: ")
+		sb.WriteString(strconv.FormatFloat(s.maxWeight, 'f', -1, 64))
+	}
+	sb.WriteString("){")
+	for k, v := range s.edges {
+		if k != 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(string(v.pred))
+		if v.facet != "" {
+			if !validVariableName(v.facet) {
+				return errors.New("invalid facet name in shortest query")
+			}
+			sb.WriteString(" @facets(")
+			sb.WriteString(v.facet)
+			sb.WriteByte(')')
+		}
+	}
+	sb.WriteByte('}')
+	return nil
+}
+
+//Path represents a single path as returned from a shortest query.
+type Path struct {
+	//The uids in the path in order, starting with from.
+	Uids []UID
+	//The total weight of the path.
+	Weight float64
+}
+
+//UnmarshalJSON deserializes the nested path structure returned by Dgraph.
+//Every node holds the next node in the path as its first edge.
+func (p *Path) UnmarshalJSON(b []byte) error {
+	p.Uids = p.Uids[:0]
+	p.Weight = 0
+	for b != nil {
+		var uid string
+		var next []byte
+		err := parse.ObjectEach(b, func(key []byte, value []byte, typ parse.ValueType, _ int) error {
+			switch {
+			case string(key) == "uid":
+				uid = string(value)
+			case string(key) == "_weight_":
+				w, err := strconv.ParseFloat(string(value), 64)
+				if err != nil {
+					return err
+				}
+				p.Weight = w
+			case next != nil:
+			case typ == parse.Object:
+				next = value
+			case typ == parse.Array:
+				var list []jsoniter.RawMessage
+				if err := json.Unmarshal(value, &list); err != nil {
+					return err
+				}
+				if len(list) > 0 {
+					next = list[0]
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if uid == "" {
+			return errors.New("missing uid in path")
+		}
+		p.Uids = append(p.Uids, UID(uid))
+		b = next
+	}
+	return nil
+}
+
+//Index returns the position of the uid in the path or -1.
+func (p Path) Index(uid UID) int {
+	for k, v := range p.Uids {
+		if v == uid {
+			return k
+		}
+	}
+	return -1
+}
+
+//Sort sorts a slice of nodes, i.e. []*User, in the order of the path
+//as nodes fetched are not returned in path order. Nodes not in the path are placed last.
+func (p Path) Sort(nodes interface{}) error {
+	val := reflect.ValueOf(nodes)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Slice {
+		return errors.New("path sort requires a slice of nodes")
+	}
+	var index = make([]int, val.Len())
+	for i := range index {
+		elem := val.Index(i)
+		if elem.Kind() != reflect.Ptr && elem.CanAddr() {
+			elem = elem.Addr()
+		}
+		node, ok := elem.Interface().(DNode)
+		if !ok {
+			return errors.New("path sort requires a slice of nodes")
+		}
+		index[i] = p.Index(node.UID())
+		if index[i] == -1 {
+			index[i] = len(p.Uids)
+		}
+	}
+	swap := reflect.Swapper(val.Interface())
+	sort.Sort(pathSorter{index: index, swap: swap})
+	return nil
+}
+
+type pathSorter struct {
+	index []int
+	swap  func(i, j int)
+}
+
+func (p pathSorter) Len() int {
+	return len(p.index)
+}
+
+func (p pathSorter) Less(i, j int) bool {
+	return p.index[i] < p.index[j]
+}
+
+func (p pathSorter) Swap(i, j int) {
+	p.index[i], p.index[j] = p.index[j], p.index[i]
+	p.swap(i, j)
+}
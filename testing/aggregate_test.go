@@ -0,0 +1,55 @@
+package gen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vliro/humus"
+)
+
+const aggregateQuery = "query t($0:string){aggNodes as var(func: type($0))@filter(has(<Question.title>)){ agg1 as count(Question.comments) " +
+	" agg2 as Post.datePublished  uid}q0(func: uid(aggNodes)){ questions : count(uid) comments : sum(val(agg1)) first : min(val(agg2)) }}"
+
+func TestAggregate(t *testing.T) {
+	var q = humus.NewQuery(nil).Function(humus.Type).Values("Question")
+	q.At("", func(m humus.Mod) {
+		m.Filter(humus.Has, QuestionTitleField)
+	})
+	a := humus.NewAggregate(q).Count("uid", "questions").Count(QuestionCommentsField, "comments").
+		Min(PostDatePublishedField, "first")
+	str, err := a.Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != aggregateQuery {
+		t.Errorf("invalid aggregate query, got %s", str)
+	}
+	if _, err := humus.NewAggregate(humus.NewQuery(nil).Function(humus.Type).Values("Question")).
+		Count("uid", "a").Count("uid", "a").Process(); err == nil {
+		t.Error("expected error on duplicate alias")
+	}
+	if _, err := humus.NewAggregate(humus.NewQuery(nil).Function(humus.Type).Values("Question")).
+		Aggregate(humus.Count, "x", "a").Process(); err == nil {
+		t.Error("expected error on count of a variable")
+	}
+}
+
+func TestAggregateResult(t *testing.T) {
+	var res humus.AggregateResult
+	err := res.UnmarshalJSON([]byte(`[{"questions":2},{"comments":3},{"first":"2020-01-02T00:00:00Z"}]`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if res.Int("questions") != 2 || res.Float("comments") != 3 {
+		t.Errorf("invalid aggregate values %d %f", res.Int("questions"), res.Float("comments"))
+	}
+	var first time.Time
+	if err := res.Decode("first", &first); err != nil || first.Year() != 2020 {
+		t.Errorf("invalid aggregate date %v %v", first, err)
+	}
+	if res.Has("missing") || res.Int("missing") != 0 {
+		t.Error("expected missing aggregate")
+	}
+}
@@ -0,0 +1,30 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+const existsQuery = "query t($0:string){aggNodes as var(func: eq(<User.email>,$0),first:1){ uid}q0(func: uid(aggNodes)){ count : count(uid) }}"
+
+const countQuery = "query t($0:string){aggNodes as var(func: type($0)){ uid}q0(func: uid(aggNodes)){ count : count(uid) }}"
+
+func TestExistsCountQuery(t *testing.T) {
+	str, err := humus.ExistsQuery(UserEmailField, "user@example.com").Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != existsQuery {
+		t.Errorf("invalid exists query, got %s", str)
+	}
+	str, err = humus.CountQuery(humus.Type, "Event").Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != countQuery {
+		t.Errorf("invalid count query, got %s", str)
+	}
+}
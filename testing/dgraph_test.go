@@ -2,10 +2,13 @@ package gen
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/Vliro/humus"
+	"github.com/dgraph-io/dgo"
 	"github.com/dgraph-io/dgo/protos/api"
 	"io/ioutil"
+	"strings"
 	"testing"
 	"time"
 )
@@ -176,6 +179,26 @@ func TestGet(t *testing.T) {
 	}
 }
 
+//Example in decoding named queries into a map.
+func TestGetNamed(t *testing.T) {
+	var qu = humus.NewQueries()
+	qu.NewQuery(questionFields).Function(humus.Equals).Values(QuestionTitleField, "First Question").Name("questions")
+	qu.NewQuery(UserFields).Function(humus.Equals).Values(UserNameField, "User").Name("users")
+	var res = make(map[string]json.RawMessage)
+	err := db.QueryMap(context.Background(), qu, res)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.Contains(string(res["questions"]), "First Question") {
+		t.Fail()
+		return
+	}
+	if _, ok := res["users"]; !ok {
+		t.Fail()
+	}
+}
+
 func BenchmarkGetQuery(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		var qu = humus.NewQueries()
@@ -186,3 +209,99 @@ func BenchmarkGetQuery(b *testing.B) {
 	}
 	b.ReportAllocs()
 }
+
+//Example in paginating over all posts, the question and the comment.
+func TestPaginator(t *testing.T) {
+	txn := db.NewTxn(true)
+	defer txn.Discard(context.Background())
+	p := humus.NewPaginator(txn, humus.NewQuery(PostFields).Function(humus.Type).Values("Post"), 1)
+	var page []*Post
+	var count int
+	for p.Next(context.Background(), &page) {
+		count += len(page)
+	}
+	if err := p.Err(); err != nil {
+		t.Error(err)
+		return
+	}
+	if count != 2 {
+		t.Errorf("invalid amount of posts %d", count)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p = humus.NewPaginator(txn, humus.NewQuery(PostFields).Function(humus.Type).Values("Post"), 1)
+	if p.Next(ctx, &page) || p.Err() == nil {
+		t.Error("expected error on cancelled context")
+	}
+}
+
+func TestExistsCount(t *testing.T) {
+	const name = "ExistsCount"
+	ok, err := db.Exists(context.Background(), UserNameField, name)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ok {
+		t.Error("expected user to not exist")
+	}
+	before, err := db.Count(context.Background(), humus.Type, "User")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := db.Mutate(context.Background(), humus.CreateMutation(&User{Name: name}, humus.MutateSet)); err != nil {
+		t.Error(err)
+		return
+	}
+	ok, err = db.Exists(context.Background(), UserNameField, name)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !ok {
+		t.Error("expected user to exist")
+	}
+	n, err := db.Count(context.Background(), humus.Type, "User")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if n != before+1 {
+		t.Errorf("invalid amount of users %d, expected %d", n, before+1)
+	}
+}
+
+func TestRunInTxn(t *testing.T) {
+	var attempts int
+	err := db.RunInTxn(context.Background(), func(txn *humus.Txn) error {
+		attempts++
+		if attempts < 3 {
+			return dgo.ErrAborted
+		}
+		_, err := txn.Mutate(context.Background(), humus.CreateMutation(&User{Name: "Retry"}, humus.MutateSet))
+		return err
+	}, &humus.TxnOptions{MaxAttempts: 3, Backoff: time.Millisecond, Jitter: 0.5})
+	if err != nil {
+		t.Error(err)
+	}
+	if attempts != 3 {
+		t.Errorf("invalid amount of attempts %d", attempts)
+	}
+	attempts = 0
+	err = db.RunInTxn(context.Background(), func(txn *humus.Txn) error {
+		attempts++
+		return dgo.ErrAborted
+	}, &humus.TxnOptions{MaxAttempts: 2, Backoff: time.Millisecond})
+	if !humus.IsAborted(err) || attempts != 2 {
+		t.Errorf("expected aborted after two attempts, got %d %v", attempts, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	err = db.RunInTxn(ctx, func(txn *humus.Txn) error {
+		return dgo.ErrAborted
+	}, &humus.TxnOptions{MaxAttempts: 10, Backoff: time.Second})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -1 +1,87 @@
 package gen
+
+import (
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+func TestFieldAlias(t *testing.T) {
+	fields := QuestionFields.Select(QuestionTitleField, QuestionCommentsField).
+		Alias(QuestionTitleField, "title").
+		Add(humus.MakeField(QuestionCommentsField, humus.MetaObject|humus.MetaList).As("latest")).
+		Sub(QuestionCommentsField, CommentFields.Select(CommentTextField)).
+		Sub("latest", CommentFields.Select(CommentTextField).Alias(CommentTextField, "text"))
+	q := humus.NewQuery(fields).Function(humus.Type).Values("Question").
+		At("latest", func(m humus.Mod) {
+			m.Paginate(humus.CountFirst, 1)
+		})
+	str, err := q.Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	const exp = "query t($0:string){q0(func: type($0)){title : Question.title Question.comments{Post.text  uid} " +
+		"latest : Question.comments(first:1){text : Post.text  uid}  uid}}"
+	if str != exp {
+		t.Errorf("invalid alias query, got %s", str)
+	}
+	_, err = humus.NewQuery(UserFields.Alias(UserNameField, "name)")).Function(humus.Type).Values("User").Process()
+	if err == nil {
+		t.Error("expected error on invalid alias")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	str, err := humus.NewQuery(humus.ExpandAll(humus.ExpandAll(nil))).Function(humus.Type).Values("Question").Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != "query t($0:string){q0(func: type($0)){expand(_all_){expand(_all_)  uid}  uid}}" {
+		t.Errorf("invalid expand query, got %s", str)
+	}
+	fields := QuestionFields.Select(QuestionTitleField).Add(humus.ExpandType(UserFields.Select(UserNameField), "Question", "Post"))
+	str, err = humus.NewQuery(fields).Function(humus.Type).Values("Question").Validate(humus.SchemaList(GetGlobalFields())).Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != "query t($0:string){q0(func: type($0)){Question.title expand(Question,Post){User.name  uid}  uid}}" {
+		t.Errorf("invalid expand type query, got %s", str)
+	}
+	_, err = humus.NewQuery(humus.ExpandType(nil, "Question)")).Function(humus.Type).Values("Question").Process()
+	if err == nil {
+		t.Error("expected error on invalid expand type")
+	}
+}
+
+func TestSubFields(t *testing.T) {
+	fields := QuestionFields.Select(QuestionTitleField, QuestionFromField, QuestionCommentsField).
+		Sub(QuestionFromField, UserFields.Select(UserNameField))
+	str, err := humus.NewQuery(fields).Function(humus.Type).Values("Question").Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	//Only the subbed edge is fetched, along with its fields.
+	const exp = "query t($0:string){q0(func: type($0)){Question.title Question.from{User.name  uid}  uid}}"
+	if str != exp {
+		t.Errorf("invalid sub query, got %s", str)
+	}
+}
+
+func TestSubEmptyFields(t *testing.T) {
+	//Edges subbed with an empty list only fetch the uid and edges which are not subbed are not fetched.
+	fields := QuestionFields.Select(QuestionTitleField, QuestionFromField, QuestionCommentsField).
+		Sub(QuestionCommentsField, humus.NewList{})
+	str, err := humus.NewQuery(fields).Function(humus.Type).Values("Question").Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	const exp = "query t($0:string){q0(func: type($0)){Question.title Question.comments{ uid}  uid}}"
+	if str != exp {
+		t.Errorf("invalid empty sub query, got %s", str)
+	}
+}
@@ -0,0 +1,77 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+//Filter trees are copied on use so the same tree can be shared between queries.
+var titleOrDateFilter = humus.And(
+	humus.NewFilter(humus.Equals, QuestionTitleField, "First Question"),
+	humus.Or(
+		humus.NewFilter(humus.Less, QuestionDatePublishedField, "2020-01-01"),
+		humus.Not(humus.NewFilter(humus.Has, QuestionFromField)),
+	),
+)
+
+const filterTreeQuery = "query t($0:string,$1:string,$2:string){q0(func: type($2))" +
+	"@filter(eq(<Question.title>,$0) AND (lt(<Post.datePublished>,$1) OR NOT has(<Question.from>)))" +
+	"{Question.title Post.text Post.datePublished  uid}}"
+
+func TestFilterTree(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		var q = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question")
+		q.At("", func(m humus.Mod) {
+			m.FilterTree(titleOrDateFilter)
+		})
+		str, err := q.Process()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if str != filterTreeQuery {
+			t.Errorf("invalid filter tree query, got %s", str)
+			return
+		}
+	}
+}
+
+func TestEmptyFilterTree(t *testing.T) {
+	var q = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question")
+	q.At("", func(m humus.Mod) {
+		m.FilterTree(humus.Or())
+	})
+	if _, err := q.Process(); err == nil {
+		t.Fail()
+	}
+}
+
+func TestNilFilterTree(t *testing.T) {
+	for _, f := range []*humus.Filter{humus.And(nil, humus.NewFilter(humus.Has, QuestionFromField)),
+		humus.Or(humus.NewFilter(humus.Has, QuestionFromField), nil), humus.Not(nil)} {
+		var q = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question")
+		q.At("", func(m humus.Mod) {
+			m.FilterTree(f)
+		})
+		if _, err := q.Process(); err == nil {
+			t.Error("expected error on nil filter")
+		}
+	}
+}
+
+func TestNestedNotFilter(t *testing.T) {
+	var q = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question")
+	q.At("", func(m humus.Mod) {
+		m.FilterTree(humus.Not(humus.Not(humus.NewFilter(humus.Has, QuestionFromField))))
+	})
+	str, err := q.Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.Contains(str, "@filter(NOT (NOT has(<Question.from>)))") {
+		t.Errorf("invalid nested not filter, got %s", str)
+	}
+}
@@ -0,0 +1,65 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+var functionTests = []struct {
+	typ    humus.FunctionType
+	values []interface{}
+	valid  bool
+}{
+	{humus.AnyOfText, []interface{}{PostTextField, "some text"}, true},
+	{humus.AnyOfText, []interface{}{PostTextField}, false},
+	{humus.Regexp, []interface{}{UserNameField, "/^Us.*$/i"}, true},
+	{humus.Regexp, []interface{}{"User.name", "/^Us.*$/i"}, false},
+	{humus.Between, []interface{}{PostDatePublishedField, "2019-01-01", "2020-01-01"}, true},
+	{humus.Between, []interface{}{PostDatePublishedField, "2019-01-01"}, false},
+	{humus.Has, []interface{}{QuestionFromField}, true},
+	{humus.Has, []interface{}{QuestionFromField, QuestionTitleField}, false},
+	{humus.UidIn, []interface{}{QuestionFromField, humus.UID("0x1")}, true},
+	{humus.UidIn, []interface{}{QuestionFromField, 1}, false},
+	{humus.Match, []interface{}{UserNameField, "User", 2}, true},
+	{humus.Near, []interface{}{"Location.loc", humus.Point(-122.46, 37.77), 1000}, false},
+	{humus.Near, []interface{}{humus.Predicate("Location.loc"), humus.Point(-122.46, 37.77), 1000}, true},
+	{humus.Within, []interface{}{humus.Predicate("Location.loc"), humus.Polygon([2]float64{1, 1}, [2]float64{1, 2}, [2]float64{2, 2}, [2]float64{1, 1})}, true},
+	{humus.Intersects, []interface{}{humus.Predicate("Location.loc"), "polygon"}, false},
+	{humus.Intersects, []interface{}{humus.Predicate("Location.loc"), humus.Geo{}}, false},
+	{humus.SimilarTo, []interface{}{humus.Predicate("Post.embedding"), 3, humus.Vector{0.1, 0.2}}, true},
+	{humus.SimilarTo, []interface{}{humus.Predicate("Post.embedding"), humus.Vector{0.1, 0.2}}, false},
+	{humus.Less.WithFunction("count"), []interface{}{QuestionCommentsField, 2}, true},
+	{humus.Type, []interface{}{QuestionTitleField}, false},
+}
+
+func TestFunctionCheck(t *testing.T) {
+	for _, v := range functionTests {
+		q := humus.NewQuery(UserFields).Function(v.typ).Values(v.values...)
+		_, err := q.Process()
+		if (err == nil) != v.valid {
+			t.Errorf("%s with %v: expected valid %v, got error %v", v.typ, v.values, v.valid, err)
+		}
+	}
+}
+
+const variableCompareQuery = "query t($0:string,$1:string){var(func: type($0)){ d as Post.datePublished  uid}" +
+	"q0(func: type($1))@filter(lt(<Post.datePublished>,val(d))){Question.title Post.text Post.datePublished  uid}}"
+
+func TestCompareVariable(t *testing.T) {
+	var q = humus.NewQueries()
+	q.NewQuery(humus.NewList{}).Function(humus.Type).Values("Question").Var("").At("", func(m humus.Mod) {
+		m.Value("d", PostDatePublishedField)
+	})
+	q.NewQuery(QuestionFields).Function(humus.Type).Values("Question").At("", func(m humus.Mod) {
+		m.Filter(humus.Less, PostDatePublishedField, humus.Variable("d"))
+	})
+	str, err := q.Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != variableCompareQuery {
+		t.Errorf("invalid variable comparison, got %s", str)
+	}
+}
@@ -0,0 +1,107 @@
+package gen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vliro/humus"
+)
+
+const groupByRootQuery = "query t($0:string){q0(func: type($0))@groupby(Question.from){ count(uid) }}"
+
+const groupByEdgeQuery = "query t($0:string){q0(func: type($0)){Question.title Question.comments@groupby(Comment.from){ count(uid) }" +
+	" Post.text Post.datePublished  uid}}"
+
+func TestGroupBy(t *testing.T) {
+	var q = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question")
+	q.GroupBy("", QuestionFromField, func(m humus.Mod) {
+		m.Aggregate(humus.Count, "uid", "")
+	})
+	str, err := q.Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != groupByRootQuery {
+		t.Errorf("invalid root groupby query, got %s", str)
+	}
+	q = humus.NewQuery(QuestionFields.Sub(QuestionCommentsField, CommentFields)).Function(humus.Type).Values("Question")
+	q.GroupBy(QuestionCommentsField, CommentFromField, func(m humus.Mod) {
+		m.Aggregate(humus.Count, "uid", "")
+	})
+	str, err = q.Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != groupByEdgeQuery {
+		t.Errorf("invalid edge groupby query, got %s", str)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	type fromCount struct {
+		From  humus.UID `predicate:"Comment.from"`
+		Count int       `predicate:"count"`
+	}
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"root", `{"@groupby":[{"Comment.from":"0x1","count":2},{"Comment.from":"0x2","count":1}]}`},
+		{"edge", `[{"@groupby":[{"Comment.from":"0x1","count":2},{"Comment.from":"0x2","count":1}]}]`},
+	}
+	for _, v := range tests {
+		var g humus.Groups
+		if err := g.UnmarshalJSON([]byte(v.data)); err != nil {
+			t.Errorf("%s: %s", v.name, err)
+			continue
+		}
+		rows, err := g.Rows(CommentFromField)
+		if err != nil {
+			t.Errorf("%s: %s", v.name, err)
+			continue
+		}
+		if len(rows) != 2 || rows[0].UID() != "0x1" || rows[0].Count() != 2 || rows[1].Count() != 1 {
+			t.Errorf("%s: invalid rows %v", v.name, rows)
+		}
+		var typed []fromCount
+		if err := g.Decode(&typed); err != nil {
+			t.Errorf("%s: %s", v.name, err)
+			continue
+		}
+		if len(typed) != 2 || typed[1].From != "0x2" || typed[1].Count != 1 {
+			t.Errorf("%s: invalid typed rows %v", v.name, typed)
+		}
+	}
+	//Aggregates keep the type of the predicate.
+	var g humus.Groups
+	if err := g.UnmarshalJSON([]byte(`{"@groupby":[{"Comment.from":"0x1","count":2,"latest":"2020-01-02T15:04:05Z","first":"a","avg":1.5}]}`)); err != nil {
+		t.Error(err)
+		return
+	}
+	rows, err := g.Rows(CommentFromField)
+	if err != nil || len(rows) != 1 {
+		t.Errorf("invalid rows %v: %v", rows, err)
+		return
+	}
+	if ts, err := rows[0].Time("latest"); err != nil || !ts.Equal(time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("invalid time aggregate %s: %v", ts, err)
+	}
+	if s, err := rows[0].String("first"); err != nil || s != "a" {
+		t.Errorf("invalid string aggregate %s: %v", s, err)
+	}
+	if f, err := rows[0].Float("avg"); err != nil || f != 1.5 {
+		t.Errorf("invalid number aggregate %f: %v", f, err)
+	}
+	if _, err := rows[0].Float("sum"); err == nil {
+		t.Error("expected error on missing aggregate")
+	}
+	if err := g.UnmarshalJSON([]byte(`{"@groupby":[{"count":2}]}`)); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := g.Rows(CommentFromField); err == nil {
+		t.Error("expected error on group without key")
+	}
+}
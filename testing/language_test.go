@@ -0,0 +1,60 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+var langFields = humus.NewList{humus.MakeField(UserNameField, humus.MetaLang), humus.MakeField(UserEmailField, humus.MetaLang)}
+
+func TestLanguages(t *testing.T) {
+	q := humus.NewQuery(langFields).Function(humus.Type).Values("User").
+		Languages("sv", "zh-Hant", humus.LanguageUntagged).
+		At(UserNameField, func(m humus.Mod) {
+			m.Language(humus.LanguageAll)
+		})
+	str, err := q.Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != "query t($0:string){q0(func: type($0)){User.name@* User.email@sv:zh-Hant:.  uid}}" {
+		t.Errorf("invalid language query, got %s", str)
+	}
+	str, err = humus.NewQuery(langFields).Function(humus.Type).Values("User").Language(humus.LanguageEnglish, true).Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != "query t($0:string){q0(func: type($0)){User.name@en User.email@en  uid}}" {
+		t.Errorf("invalid language query, got %s", str)
+	}
+	var invalid = [][]humus.Language{
+		{"en)"},
+		{humus.LanguageUntagged, "en"},
+		{"en", humus.LanguageAll},
+		{"1en"},
+	}
+	for _, v := range invalid {
+		_, err := humus.NewQuery(langFields).Function(humus.Type).Values("User").Languages(v...).Process()
+		if err == nil {
+			t.Errorf("expected error on languages %v", v)
+		}
+	}
+}
+
+func TestLangString(t *testing.T) {
+	var l humus.LangString
+	if err := l.UnmarshalJSON([]byte(`"hej"`)); err != nil {
+		t.Error(err)
+		return
+	}
+	l[humus.LanguageEnglish] = "hello"
+	if v := l.Get(humus.LanguageGerman, humus.LanguageEnglish); v != "hello" {
+		t.Errorf("invalid fallback value %s", v)
+	}
+	if v := l.Get(humus.LanguageGerman, humus.LanguageNone); v != "hej" {
+		t.Errorf("invalid untagged value %s", v)
+	}
+}
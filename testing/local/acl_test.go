@@ -0,0 +1,101 @@
+package local
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+	"github.com/dgraph-io/dgo/protos/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//aclAlpha is an alpha with ACLs where the access token expires on request
+//until logging in again.
+type aclAlpha struct {
+	alpha
+	logins  int64
+	expired int32
+}
+
+func (a *aclAlpha) Login(_ context.Context, in *api.LoginRequest) (*api.Response, error) {
+	if in.Userid != "groot" || in.Password != "password" {
+		return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+	}
+	atomic.AddInt64(&a.logins, 1)
+	atomic.StoreInt32(&a.expired, 0)
+	return &api.Response{}, nil
+}
+
+func (a *aclAlpha) Query(ctx context.Context, in *api.Request) (*api.Response, error) {
+	if atomic.LoadInt32(&a.expired) == 1 {
+		return nil, status.Error(codes.Unauthenticated, "Token is expired")
+	}
+	return a.alpha.Query(ctx, in)
+}
+
+func TestLogin(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := new(aclAlpha)
+	s := grpc.NewServer()
+	api.RegisterDgraphServer(s, a)
+	go s.Serve(lis)
+	defer s.Stop()
+	conf := humus.Config{
+		Endpoints: []string{lis.Addr().String()},
+		Schema:    gen.GetGlobalFields(),
+		User:      "groot",
+		Password:  "password",
+	}
+	d, err := humus.Connect(context.Background(), &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+	if n := atomic.LoadInt64(&a.logins); n != 1 {
+		t.Fatalf("invalid amount of logins on connect %d", n)
+	}
+	//The query is run again after logging in.
+	atomic.StoreInt32(&a.expired, 1)
+	var res humus.AggregateResult
+	if err := d.Query(context.Background(), humus.CountQuery(humus.Type, "User"), &res); err != nil {
+		t.Error(err)
+	}
+	if n := atomic.LoadInt64(&a.logins); n != 2 {
+		t.Errorf("invalid amount of logins after expired token %d", n)
+	}
+	//Queries failing concurrently log in once.
+	atomic.StoreInt32(&a.expired, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var res humus.AggregateResult
+			if err := d.Query(context.Background(), humus.CountQuery(humus.Type, "User"), &res); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt64(&a.logins); n != 3 {
+		t.Errorf("invalid amount of logins after concurrent expired tokens %d", n)
+	}
+	for _, v := range []humus.Config{
+		{Endpoints: conf.Endpoints, Schema: conf.Schema, User: "groot", Password: "wrong"},
+		{Endpoints: conf.Endpoints, Schema: conf.Schema, Password: "password"},
+	} {
+		if d, err := humus.Connect(context.Background(), &v); err == nil {
+			d.Cleanup()
+			t.Errorf("expected login error for %q", v.User)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+)
+
+//aliasQuestion holds the aliased fields of a question.
+type aliasQuestion struct {
+	Uid      humus.UID `predicate:"uid"`
+	Title    string    `predicate:"title"`
+	Comments []struct {
+		Text string `predicate:"Post.text"`
+	} `predicate:"Question.comments"`
+	Latest []struct {
+		Text string `predicate:"text"`
+	} `predicate:"latest"`
+}
+
+func TestAliasDecode(t *testing.T) {
+	d := responseDB(`{"q0":[{"uid":"0x1","title":"Title","Question.comments":[{"Post.text":"First"},{"Post.text":"Second"}],"latest":[{"text":"Second"}]}]}`)
+	defer d.Cleanup()
+	fields := gen.QuestionFields.Select(gen.QuestionTitleField, gen.QuestionCommentsField).
+		Alias(gen.QuestionTitleField, "title").
+		Add(humus.MakeField(gen.QuestionCommentsField, humus.MetaObject|humus.MetaList).As("latest")).
+		Sub(gen.QuestionCommentsField, gen.CommentFields.Select(gen.CommentTextField)).
+		Sub("latest", gen.CommentFields.Select(gen.CommentTextField).Alias(gen.CommentTextField, "text"))
+	q := humus.NewQuery(fields).Function(humus.Type).Values("Question").
+		At("latest", func(m humus.Mod) {
+			m.Paginate(humus.CountFirst, 1)
+		})
+	var questions []aliasQuestion
+	if err := d.Query(context.Background(), q, &questions); err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 1 {
+		t.Fatalf("invalid amount of questions %d", len(questions))
+	}
+	v := questions[0]
+	if v.Uid != "0x1" || v.Title != "Title" || len(v.Comments) != 2 || len(v.Latest) != 1 || v.Latest[0].Text != "Second" {
+		t.Errorf("invalid question %+v", v)
+	}
+}
@@ -0,0 +1,137 @@
+package local
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+	"github.com/dgraph-io/dgo/protos/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//alpha is an in-process stand-in for a Dgraph alpha.
+type alpha struct {
+	queries int64
+	//Queries fail as unavailable if set.
+	unavailable int32
+}
+
+func (a *alpha) Login(context.Context, *api.LoginRequest) (*api.Response, error) {
+	return &api.Response{}, nil
+}
+
+func (a *alpha) Query(context.Context, *api.Request) (*api.Response, error) {
+	atomic.AddInt64(&a.queries, 1)
+	if atomic.LoadInt32(&a.unavailable) == 1 {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+	return &api.Response{Json: []byte("{}"), Txn: &api.TxnContext{}}, nil
+}
+
+func (a *alpha) Alter(context.Context, *api.Operation) (*api.Payload, error) {
+	return &api.Payload{}, nil
+}
+
+func (a *alpha) CommitOrAbort(_ context.Context, in *api.TxnContext) (*api.TxnContext, error) {
+	return in, nil
+}
+
+func (a *alpha) CheckVersion(context.Context, *api.Check) (*api.Version, error) {
+	return &api.Version{Tag: "test"}, nil
+}
+
+//startAlpha serves an alpha on a local port.
+func startAlpha(t *testing.T) (*alpha, *grpc.Server, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := new(alpha)
+	s := grpc.NewServer()
+	api.RegisterDgraphServer(s, a)
+	go s.Serve(lis)
+	return a, s, lis.Addr().String()
+}
+
+//waitHealth waits until the health of the endpoint at addr is healthy.
+func waitHealth(d *humus.DB, addr string, healthy bool) bool {
+	for i := 0; i < 100; i++ {
+		for _, v := range d.Endpoints() {
+			if v.Addr == addr && v.Healthy == healthy {
+				return true
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestBalancerFailover(t *testing.T) {
+	_, first, firstAddr := startAlpha(t)
+	second, s, secondAddr := startAlpha(t)
+	defer s.Stop()
+	d := humus.Init(&humus.Config{
+		Endpoints:      []string{firstAddr, secondAddr},
+		Balance:        humus.BalanceLeastLoaded,
+		HealthInterval: 10 * time.Millisecond,
+	}, gen.GetGlobalFields())
+	defer d.Cleanup()
+	if len(d.Endpoints()) != 2 {
+		t.Fatalf("invalid amount of endpoints %d", len(d.Endpoints()))
+	}
+	first.Stop()
+	if !waitHealth(d, firstAddr, false) {
+		t.Fatal("expected stopped alpha to be unhealthy")
+	}
+	if !waitHealth(d, secondAddr, true) {
+		t.Fatal("expected running alpha to be healthy")
+	}
+	//All queries fail over to the running alpha.
+	for i := 0; i < 3; i++ {
+		var res humus.AggregateResult
+		if err := d.Query(context.Background(), humus.CountQuery(humus.Type, "User"), &res); err != nil {
+			t.Error(err)
+		}
+	}
+	if n := atomic.LoadInt64(&second.queries); n != 3 {
+		t.Errorf("invalid amount of queries on running alpha %d", n)
+	}
+}
+
+func TestBalancerRetry(t *testing.T) {
+	first, s1, firstAddr := startAlpha(t)
+	defer s1.Stop()
+	second, s2, secondAddr := startAlpha(t)
+	defer s2.Stop()
+	atomic.StoreInt32(&first.unavailable, 1)
+	atomic.StoreInt32(&second.unavailable, 1)
+	d := humus.Init(&humus.Config{
+		Endpoints:      []string{firstAddr, secondAddr},
+		HealthInterval: time.Hour,
+	}, gen.GetGlobalFields())
+	defer d.Cleanup()
+	queries := func() int64 {
+		return atomic.LoadInt64(&first.queries) + atomic.LoadInt64(&second.queries)
+	}
+	//Read only queries are tried on every alpha.
+	var res humus.AggregateResult
+	if err := d.Query(context.Background(), humus.CountQuery(humus.Type, "User"), &res); err == nil {
+		t.Error("expected error on unavailable alphas")
+	}
+	if n := queries(); n != 2 {
+		t.Errorf("invalid amount of read only attempts %d", n)
+	}
+	//Mutations are not repeated.
+	if _, err := d.Mutate(context.Background(), humus.CreateMutation(&gen.User{Name: "Retry"}, humus.MutateSet)); err == nil {
+		t.Error("expected error on unavailable alphas")
+	}
+	if n := queries(); n != 3 {
+		t.Errorf("invalid amount of mutation attempts %d", n-2)
+	}
+}
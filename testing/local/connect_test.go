@@ -0,0 +1,130 @@
+package local
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+	"github.com/dgraph-io/dgo/protos/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestConnectInvalid(t *testing.T) {
+	var tests = []struct {
+		name string
+		conf humus.Config
+	}{
+		{"schema", humus.Config{Endpoints: []string{"localhost:9080"}}},
+		{"endpoint", humus.Config{Endpoints: []string{"localhost"}, Schema: gen.GetGlobalFields()}},
+		{"port", humus.Config{IP: "localhost", Port: 70000, Schema: gen.GetGlobalFields()}},
+		{"tls", humus.Config{Endpoints: []string{"localhost:9080"}, ServerName: "alpha", Schema: gen.GetGlobalFields()}},
+		{"key", humus.Config{Endpoints: []string{"localhost:9080"}, Tls: true, CertPEM: []byte("cert"), Schema: gen.GetGlobalFields()}},
+		{"ca", humus.Config{Endpoints: []string{"localhost:9080"}, Tls: true, RootCAPEM: []byte("ca"), Schema: gen.GetGlobalFields()}},
+		{"cert", humus.Config{Endpoints: []string{"localhost:9080"}, Tls: true, CertPEM: []byte("cert"),
+			KeyPEM: []byte("key"), Schema: gen.GetGlobalFields()}},
+	}
+	for _, v := range tests {
+		if _, err := humus.Connect(context.Background(), &v.conf); err == nil {
+			t.Errorf("%s: expected error", v.name)
+		}
+	}
+}
+
+//certificate creates a certificate signed by parent, or self-signed if nil, returning it in PEM.
+func certificate(t *testing.T, parent *tls.Certificate, host string, ca bool) (tls.Certificate, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  ca,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signKey := tmpl, interface{}(key)
+	if parent != nil {
+		signer, signKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert.Leaf, _ = x509.ParseCertificate(der)
+	return cert, certPEM, keyPEM
+}
+
+func TestConnectMutualTLS(t *testing.T) {
+	ca, caPEM, _ := certificate(t, nil, "ca", true)
+	server, _, _ := certificate(t, &ca, "alpha.test", false)
+	_, clientPEM, clientKey := certificate(t, &ca, "client", false)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPEM)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{server},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+	api.RegisterDgraphServer(s, new(alpha))
+	go s.Serve(lis)
+	defer s.Stop()
+	conf := humus.Config{
+		Endpoints:        []string{lis.Addr().String()},
+		Schema:           gen.GetGlobalFields(),
+		Tls:              true,
+		RootCAPEM:        caPEM,
+		CertPEM:          clientPEM,
+		KeyPEM:           clientKey,
+		ServerName:       "alpha.test",
+		KeepaliveTime:    time.Minute,
+		KeepaliveTimeout: time.Second,
+		MaxRecvMsgSize:   1 << 20,
+		DialOptions:      []grpc.DialOption{grpc.WithUserAgent("humus-test")},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	d, err := humus.Connect(ctx, &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Cleanup()
+	//The alpha requires a client certificate.
+	conf.CertPEM, conf.KeyPEM = nil, nil
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if d, err := humus.Connect(ctx, &conf); err == nil {
+		d.Cleanup()
+		t.Error("expected error without client certificate")
+	}
+}
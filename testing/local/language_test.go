@@ -0,0 +1,61 @@
+package local
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+	"github.com/dgraph-io/dgo/protos/api"
+)
+
+//langUserFields fetches the @lang name in every language.
+var langUserFields = humus.NewList{humus.MakeField(gen.UserNameField, humus.MetaLang), humus.MakeField(gen.UserEmailField, 0)}
+
+//langUser is a user with a @lang name as generated.
+type langUser struct {
+	humus.Node
+	Name  humus.LangString `json:"name,omitempty" predicate:"User.name,omitempty"`
+	Email string           `json:"email,omitempty" predicate:"User.email,omitempty"`
+}
+
+func TestLangStringNode(t *testing.T) {
+	var req humus.Request
+	d := responseDB(`{"q0":[{"uid":"0x1","User.name":"namn","User.name@sv":"hej","User.name@en":"hi","User.other@en":"x","User.email":"a@b"}]}`,
+		func(next humus.Handler) humus.Handler {
+			return func(ctx context.Context, r *humus.Request) (*api.Response, error) {
+				req = *r
+				return next(ctx, r)
+			}
+		})
+	defer d.Cleanup()
+	var users []langUser
+	q := humus.NewQuery(langUserFields).Function(humus.Type).Values("User").Languages(humus.LanguageAll)
+	if err := d.Query(context.Background(), q, &users); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(req.Query, "{User.name@* User.email ") {
+		t.Errorf("invalid language query %s", req.Query)
+	}
+	if len(users) != 1 {
+		t.Fatalf("invalid amount of users %d", len(users))
+	}
+	u := users[0]
+	if u.Uid != "0x1" || u.Email != "a@b" || len(u.Name) != 3 || u.Name[humus.LanguageNone] != "namn" ||
+		u.Name["sv"] != "hej" || u.Name[humus.LanguageEnglish] != "hi" {
+		t.Errorf("invalid user %+v", u)
+	}
+	//Languages are written in order and fallback chains are dropped.
+	u.Name["sv:en"] = "chain"
+	if _, err := d.Mutate(context.Background(), humus.CreateMutation(&u, humus.MutateSet)); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Mutations) != 1 {
+		t.Fatalf("invalid amount of mutations %d", len(req.Mutations))
+	}
+	const expected = `{"uid":"0x1","User.name":"namn","User.name@en":"hi","User.name@sv":"hej","User.email":"a@b"}`
+	if string(req.Mutations[0].SetJson) != expected {
+		t.Errorf("invalid encoding %s", req.Mutations[0].SetJson)
+	}
+}
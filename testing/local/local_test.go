@@ -0,0 +1,28 @@
+//Package local tests humus without a running Dgraph, either by answering requests
+//in a middleware or using an in-process alpha.
+package local
+
+import (
+	"context"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+	"github.com/dgraph-io/dgo/protos/api"
+)
+
+//unusedAddr is an address without an alpha. Connections are made lazily so
+//requests answered in a middleware never reach it.
+const unusedAddr = "127.0.0.1:1"
+
+//responseDB returns a DB answering every request with the json after
+//running the middleware.
+func responseDB(json string, m ...humus.Middleware) *humus.DB {
+	d := humus.Init(&humus.Config{Endpoints: []string{unusedAddr}}, gen.GetGlobalFields())
+	d.Use(m...)
+	d.Use(func(next humus.Handler) humus.Handler {
+		return func(ctx context.Context, req *humus.Request) (*api.Response, error) {
+			return &api.Response{Json: []byte(json), Txn: &api.TxnContext{}}, nil
+		}
+	})
+	return d
+}
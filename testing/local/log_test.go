@@ -0,0 +1,100 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+)
+
+type logEntry struct {
+	level  humus.LogLevel
+	msg    string
+	fields map[string]string
+}
+
+//recordLogger records all entries.
+type recordLogger struct {
+	entries []logEntry
+}
+
+func (r *recordLogger) Log(level humus.LogLevel, msg string, fields ...humus.LogField) {
+	var e = logEntry{level: level, msg: msg, fields: make(map[string]string)}
+	for _, v := range fields {
+		e.fields[v.Key] = fmt.Sprint(v.Value)
+	}
+	r.entries = append(r.entries, e)
+}
+
+func TestLogRedact(t *testing.T) {
+	_, s, addr := startAlpha(t)
+	defer s.Stop()
+	//The email is ignored in the schema as a password would be.
+	var sch = make(humus.SchemaList)
+	for k, v := range gen.GetGlobalFields() {
+		sch[k] = v
+	}
+	email := sch[gen.UserEmailField]
+	email.Meta |= humus.MetaIgnore
+	sch[gen.UserEmailField] = email
+	l := new(recordLogger)
+	d := humus.Init(&humus.Config{
+		Endpoints: []string{addr},
+		Logger:    l,
+		SlowQuery: time.Nanosecond,
+		Redact:    []humus.Predicate{gen.UserNameField},
+	}, sch)
+	defer d.Cleanup()
+	var users []gen.User
+	q := humus.GetByPredicate(gen.UserEmailField, gen.UserFields, "secret@example.com")
+	if err := d.Query(context.Background(), q, &users); err != nil {
+		t.Error(err)
+	}
+	//Several values as well as filters and prepared queries are redacted.
+	q = humus.GetByPredicate(gen.UserEmailField, gen.UserFields, "secret@example.com", "secret@example.org")
+	q.At("", func(m humus.Mod) {
+		m.Filter(humus.Equals, gen.UserNameField, "Secret Name")
+	})
+	if err := d.Query(context.Background(), q, &users); err != nil {
+		t.Error(err)
+	}
+	p, err := humus.NewQuery(gen.UserFields).Function(humus.Equals).Values(gen.UserNameField, "").Prepare()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Query(context.Background(), p.Bind("Secret Name"), &users); err != nil {
+		t.Error(err)
+	}
+	if _, err := d.Mutate(context.Background(), humus.CreateMutation(&gen.User{Name: "Secret Name", Email: "secret@example.com"},
+		humus.MutateSet)); err != nil {
+		t.Error(err)
+	}
+	if len(l.entries) != 4 {
+		t.Fatalf("invalid amount of log entries %d", len(l.entries))
+	}
+	for _, v := range l.entries {
+		if v.level != humus.LevelWarn {
+			t.Errorf("expected slow entry at warn level, got %s", v.level)
+		}
+		for key, val := range v.fields {
+			if strings.Contains(val, "secret") || strings.Contains(val, "Secret") {
+				t.Errorf("unredacted value in %s: %s", key, val)
+			}
+		}
+	}
+	query := l.entries[0]
+	if query.msg != "dgraph query" || query.fields["names"] != "q0" || query.fields["vars"] != "1" ||
+		query.fields["query"] == "" || !strings.Contains(query.fields["variables"], "***") {
+		t.Errorf("invalid query entry %v", query)
+	}
+	if query = l.entries[1]; query.fields["vars"] != "3" || strings.Count(query.fields["variables"], "***") != 3 {
+		t.Errorf("invalid filtered query entry %v", query)
+	}
+	if !strings.Contains(l.entries[3].fields["mutation0.set"], `"User.email":"***"`) {
+		t.Errorf("invalid mutation entry %v", l.entries[3])
+	}
+}
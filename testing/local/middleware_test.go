@@ -0,0 +1,97 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+	"github.com/dgraph-io/dgo/protos/api"
+)
+
+func TestMiddleware(t *testing.T) {
+	_, s, addr := startAlpha(t)
+	defer s.Stop()
+	d := humus.Init(&humus.Config{Endpoints: []string{addr}}, gen.GetGlobalFields())
+	defer d.Cleanup()
+	var order []string
+	var reqs []humus.Request
+	var errFault = errors.New("fault")
+	d.Use(func(next humus.Handler) humus.Handler {
+		return func(ctx context.Context, req *humus.Request) (*api.Response, error) {
+			order = append(order, "outer")
+			reqs = append(reqs, *req)
+			return next(ctx, req)
+		}
+	}, func(next humus.Handler) humus.Handler {
+		return func(ctx context.Context, req *humus.Request) (*api.Response, error) {
+			order = append(order, "inner")
+			if req.Kind == humus.RequestMutate {
+				return nil, errFault
+			}
+			return next(ctx, req)
+		}
+	})
+	var res humus.AggregateResult
+	q := humus.CountQuery(humus.Type, "User")
+	if err := d.Query(context.Background(), q, &res); err != nil {
+		t.Error(err)
+	}
+	if _, err := d.Mutate(context.Background(), humus.CreateMutation(&gen.User{Name: "Middleware"}, humus.MutateSet)); err != errFault {
+		t.Errorf("expected fault from middleware, got %v", err)
+	}
+	if len(order) != 4 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("invalid middleware order %v", order)
+	}
+	str, _ := q.Process()
+	if len(reqs) != 2 || reqs[0].Kind != humus.RequestQuery || reqs[0].Query != str || reqs[0].Vars["$0"] != "User" {
+		t.Errorf("invalid query request %v", reqs)
+		return
+	}
+	if reqs[1].Kind != humus.RequestMutate || len(reqs[1].Mutations) != 1 || len(reqs[1].Mutations[0].SetJson) == 0 {
+		t.Errorf("invalid mutate request %v", reqs[1])
+	}
+}
+
+func TestMiddlewareConcurrentUse(t *testing.T) {
+	d := responseDB(`{"q0":[]}`)
+	defer d.Cleanup()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			//The request is copied before it is passed on.
+			d.Use(func(next humus.Handler) humus.Handler {
+				return func(ctx context.Context, req *humus.Request) (*api.Response, error) {
+					r := *req
+					return next(ctx, &r)
+				}
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			var res humus.AggregateResult
+			if err := d.Query(context.Background(), humus.CountQuery(humus.Type, "User"), &res); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	//A new request has no call to perform.
+	_, s, addr := startAlpha(t)
+	defer s.Stop()
+	d = humus.Init(&humus.Config{Endpoints: []string{addr}}, gen.GetGlobalFields())
+	defer d.Cleanup()
+	d.Use(func(next humus.Handler) humus.Handler {
+		return func(ctx context.Context, req *humus.Request) (*api.Response, error) {
+			return next(ctx, &humus.Request{Kind: req.Kind})
+		}
+	})
+	var res humus.AggregateResult
+	if err := d.Query(context.Background(), humus.CountQuery(humus.Type, "User"), &res); err == nil {
+		t.Error("expected error on request without call")
+	}
+}
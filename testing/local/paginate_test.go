@@ -0,0 +1,84 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+	"github.com/dgraph-io/dgo/protos/api"
+)
+
+//pageDB returns a database answering the queries with pages in order
+//and recording the variables of every query.
+func pageDB(pages []string, vars *[]map[string]string) *humus.DB {
+	return responseDB("", func(next humus.Handler) humus.Handler {
+		return func(ctx context.Context, req *humus.Request) (*api.Response, error) {
+			page := pages[len(*vars)]
+			*vars = append(*vars, req.Vars)
+			return &api.Response{Json: []byte(page), Txn: &api.TxnContext{}}, nil
+		}
+	})
+}
+
+func hasValue(vars map[string]string, val string) bool {
+	for _, v := range vars {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+func paginate(t *testing.T, pages []string) (int, []map[string]string) {
+	var vars []map[string]string
+	d := pageDB(pages, &vars)
+	defer d.Cleanup()
+	txn := d.NewTxn(true)
+	p := humus.NewPaginator(txn, humus.NewQuery(gen.PostFields).Function(humus.Type).Values("Post"), 2)
+	var page []*gen.Post
+	var count int
+	for p.Next(context.Background(), &page) {
+		count += len(page)
+	}
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return count, vars
+}
+
+func TestPaginatorAfter(t *testing.T) {
+	//A short page ends paging without another query.
+	count, vars := paginate(t, []string{
+		`{"q0":[{"uid":"0x1"},{"uid":"0x2"}]}`,
+		`{"q0":[{"uid":"0x3"}]}`,
+	})
+	if count != 3 || len(vars) != 2 {
+		t.Fatalf("invalid paging, %d nodes in %d queries", count, len(vars))
+	}
+	if !hasValue(vars[0], "0x0") || !hasValue(vars[1], "0x2") {
+		t.Errorf("after is not advanced %v", vars)
+	}
+}
+
+func TestPaginatorEmpty(t *testing.T) {
+	//An empty page ends paging.
+	count, vars := paginate(t, []string{
+		`{"q0":[{"uid":"0x1"},{"uid":"0x2"}]}`,
+		`{"q0":[]}`,
+	})
+	if count != 2 || len(vars) != 2 {
+		t.Fatalf("invalid paging, %d nodes in %d queries", count, len(vars))
+	}
+}
+
+func TestPaginatorNil(t *testing.T) {
+	var vars []map[string]string
+	d := pageDB([]string{`{"q0":[{"uid":"0x1"},null]}`}, &vars)
+	defer d.Cleanup()
+	p := humus.NewPaginator(d.NewTxn(true), humus.NewQuery(gen.PostFields).Function(humus.Type).Values("Post"), 2)
+	var page []*gen.Post
+	if p.Next(context.Background(), &page) || p.Err() == nil {
+		t.Error("expected error on a nil node")
+	}
+}
@@ -0,0 +1,46 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+	"github.com/dgraph-io/dgo/protos/api"
+)
+
+func TestPrepareBind(t *testing.T) {
+	var req humus.Request
+	d := responseDB(`{"q0":[]}`, func(next humus.Handler) humus.Handler {
+		return func(ctx context.Context, r *humus.Request) (*api.Response, error) {
+			req = *r
+			return next(ctx, r)
+		}
+	})
+	defer d.Cleanup()
+	q := humus.NewQuery(gen.QuestionFields).Function(humus.Equals).Values(gen.QuestionTitleField, "Title")
+	q.At(gen.QuestionCommentsField, func(m humus.Mod) {
+		m.Filter(humus.Equals, gen.PostTextField, "Comment")
+	})
+	q.At("", func(m humus.Mod) {
+		m.Filter(humus.Has, gen.QuestionFromField)
+		m.Filter(humus.Equals, gen.PostTextField, "Text")
+	})
+	p, err := q.Prepare()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Params() != 3 {
+		t.Fatalf("invalid amount of params %d", p.Params())
+	}
+	var questions []gen.Question
+	if err := d.Query(context.Background(), p.Bind("First", "Comment", "Text"), &questions); err != nil {
+		t.Fatal(err)
+	}
+	//Every value is bound to the variable of its own argument.
+	for key, exp := range map[string]string{"$0": "Comment", "$1": "Text", "$2": "First"} {
+		if req.Vars[key] != exp {
+			t.Errorf("invalid value for %s: %s in %s with %v", key, req.Vars[key], req.Query, req.Vars)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+	"github.com/dgraph-io/dgo"
+	"github.com/dgraph-io/dgo/protos/api"
+)
+
+const namedResult = `{"questions":[{"uid":"0x1","Question.title":"First Question"}],"users":[{"uid":"0x2"}]}`
+
+func TestQueryMap(t *testing.T) {
+	d := responseDB(namedResult)
+	defer d.Cleanup()
+	var q = humus.NewQueries()
+	q.NewQuery(gen.QuestionFields).Function(humus.Type).Values("Question").Name("questions")
+	q.NewQuery(gen.UserFields).Function(humus.Type).Values("User").Name("users")
+	var res map[string]json.RawMessage
+	if err := d.QueryMap(context.Background(), q, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || len(res["users"]) == 0 {
+		t.Errorf("invalid map result %v", res)
+	}
+	//Maps are only decoded positionally by Query.
+	var questions map[string]interface{}
+	var users []gen.User
+	if err := d.Query(context.Background(), q, &questions, &users); err != nil {
+		t.Fatal(err)
+	}
+	if questions["Question.title"] != "First Question" || len(users) != 1 {
+		t.Errorf("invalid positional result %v %v", questions, users)
+	}
+	if err := d.QueryMap(context.Background(), q, users); err == nil {
+		t.Error("expected error on query map without a map")
+	}
+}
+
+//omittedResult leaves out the empty middle block as Dgraph does.
+const omittedResult = `{"questions":[{"uid":"0x1","Question.title":"First Question"}],"users":[{"uid":"0x2","User.name":"User"}]}`
+
+func TestQueryOmittedBlock(t *testing.T) {
+	d := responseDB(omittedResult)
+	defer d.Cleanup()
+	var q = humus.NewQueries()
+	q.NewQuery(gen.QuestionFields).Function(humus.Type).Values("Question").Name("questions")
+	q.NewQuery(gen.PostFields).Function(humus.Type).Values("Post").Name("posts")
+	q.NewQuery(gen.UserFields).Function(humus.Type).Values("User").Name("users")
+	var questions []gen.Question
+	var posts []gen.Post
+	var users []gen.User
+	if err := d.Query(context.Background(), q, &questions, &posts, &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 1 || questions[0].Title != "First Question" {
+		t.Errorf("invalid questions %v", questions)
+	}
+	if len(posts) != 0 {
+		t.Errorf("expected no posts, got %v", posts)
+	}
+	if len(users) != 1 || users[0].Name != "User" || users[0].Uid != "0x2" {
+		t.Errorf("invalid users %v", users)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	d := responseDB("", func(next humus.Handler) humus.Handler {
+		return func(ctx context.Context, req *humus.Request) (*api.Response, error) {
+			return nil, dgo.ErrAborted
+		}
+	})
+	defer d.Cleanup()
+	var users []gen.User
+	err := d.Query(context.Background(), humus.NewQuery(gen.UserFields).Function(humus.Type).Values("User"), &users)
+	//The error is wrapped once.
+	if !humus.IsAborted(err) || strings.Count(err.Error(), "mulbase") != 1 {
+		t.Errorf("invalid query error %v", err)
+	}
+}
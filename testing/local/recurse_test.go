@@ -0,0 +1,33 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+)
+
+const recurseResult = `{"q0":[{"uid":"0x1","Question.title":"First Question",
+	"Question.from":{"uid":"0x2"},
+	"Question.comments":[{"uid":"0x3","Post.text":"First reply"},{"uid":"0x4","Post.text":"Second reply"}]}]}`
+
+func TestRecurseDecode(t *testing.T) {
+	d := responseDB(recurseResult)
+	defer d.Cleanup()
+	var q = humus.NewQuery(gen.QuestionFields).Function(humus.Type).Values("Question").Recurse(3, false)
+	var questions []gen.Question
+	if err := d.Query(context.Background(), q, &questions); err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 1 {
+		t.Fatalf("invalid amount of questions %d", len(questions))
+	}
+	question := questions[0]
+	if question.Title != "First Question" || question.From == nil || question.From.Uid != "0x2" {
+		t.Errorf("invalid question %+v", question)
+	}
+	if len(question.Comments) != 2 || question.Comments[0].Text != "First reply" || question.Comments[1].Uid != "0x4" {
+		t.Errorf("invalid nested comments %+v", question.Comments)
+	}
+}
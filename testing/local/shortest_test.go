@@ -0,0 +1,33 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+)
+
+//Dgraph returns the paths of both shortest blocks under _path_.
+const shortestResult = `{"q0":[{"uid":"0x1"},{"uid":"0x3"}],"q1":[{"uid":"0x2"},{"uid":"0x5"}],` +
+	`"_path_":[{"uid":"0x1","Question.from":{"uid":"0x3"},"_weight_":1},` +
+	`{"uid":"0x2","Question.from":{"uid":"0x5"},"_weight_":1}]}`
+
+func TestShortestDecode(t *testing.T) {
+	d := responseDB(shortestResult)
+	defer d.Cleanup()
+	var q = humus.NewQueries()
+	q.Shortest("0x1", "0x3", gen.UserFields).Edge(gen.QuestionFromField)
+	q.Shortest("0x2", "0x5", gen.UserFields).Edge(gen.QuestionFromField)
+	var first, second []humus.Path
+	var firstUsers, secondUsers []gen.User
+	if err := d.Query(context.Background(), q, &first, &firstUsers, &second, &secondUsers); err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 1 || first[0].Uids[0] != "0x1" || len(second) != 1 || second[0].Uids[1] != "0x5" {
+		t.Errorf("invalid paths %v %v", first, second)
+	}
+	if len(firstUsers) != 2 || len(secondUsers) != 2 || secondUsers[1].Uid != "0x5" {
+		t.Errorf("invalid path nodes %v %v", firstUsers, secondUsers)
+	}
+}
@@ -0,0 +1,94 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vliro/humus"
+	gen "github.com/Vliro/humus/testing"
+	"github.com/dgraph-io/dgo/protos/api"
+)
+
+type traceKey struct{}
+
+//recordSpan is a finished span.
+type recordSpan struct {
+	operation string
+	tags      map[string]interface{}
+	finished  bool
+	err       error
+}
+
+func (r *recordSpan) SetTag(key string, value interface{}) {
+	r.tags[key] = value
+}
+
+func (r *recordSpan) Finish(err error) {
+	r.finished = true
+	r.err = err
+}
+
+//recordTracer records all spans and marks their context.
+type recordTracer struct {
+	spans []*recordSpan
+}
+
+func (r *recordTracer) StartSpan(ctx context.Context, operation string) (context.Context, humus.Span) {
+	s := &recordSpan{operation: operation, tags: make(map[string]interface{})}
+	r.spans = append(r.spans, s)
+	return context.WithValue(ctx, traceKey{}, s), s
+}
+
+func TestTrace(t *testing.T) {
+	_, s, addr := startAlpha(t)
+	defer s.Stop()
+	tr := new(recordTracer)
+	d := humus.Init(&humus.Config{Endpoints: []string{addr}, Tracer: tr}, gen.GetGlobalFields())
+	defer d.Cleanup()
+	//Respond as dgraph would, ensuring the span is in the context.
+	d.Use(func(next humus.Handler) humus.Handler {
+		return func(ctx context.Context, req *humus.Request) (*api.Response, error) {
+			if ctx.Value(traceKey{}) == nil {
+				t.Errorf("span not in context of %s", req.Kind)
+			}
+			return &api.Response{
+				Json:    []byte("{}"),
+				Txn:     &api.TxnContext{StartTs: 42},
+				Latency: &api.Latency{ParsingNs: 1, ProcessingNs: 2, EncodingNs: 3},
+			}, nil
+		}
+	})
+	txn := d.NewTxn(false)
+	var res humus.AggregateResult
+	if err := txn.Query(context.Background(), humus.CountQuery(humus.Type, "User"), &res); err != nil {
+		t.Error(err)
+	}
+	if _, err := txn.Mutate(context.Background(), humus.CreateMutation(&gen.User{Name: "Trace"}, humus.MutateSet)); err != nil {
+		t.Error(err)
+	}
+	if err := txn.Commit(context.Background()); err != nil {
+		t.Error(err)
+	}
+	_ = txn.Discard(context.Background())
+	var ops = []string{humus.SpanQuery, humus.SpanMutate, humus.SpanCommit, humus.SpanDiscard}
+	if len(tr.spans) != len(ops) {
+		t.Fatalf("invalid amount of spans %d", len(tr.spans))
+	}
+	for k, v := range tr.spans {
+		if v.operation != ops[k] || !v.finished || v.err != nil {
+			t.Errorf("invalid span %v", v)
+		}
+		if v.tags[humus.TagStartTs] != uint64(42) {
+			t.Errorf("invalid start timestamp in %s: %v", v.operation, v.tags[humus.TagStartTs])
+		}
+	}
+	query := tr.spans[0]
+	if query.tags[humus.TagNames] != "q0" || query.tags[humus.TagMutations] != 0 ||
+		query.tags[humus.TagParsingNs] != uint64(1) || query.tags[humus.TagProcessingNs] != uint64(2) ||
+		query.tags[humus.TagEncodingNs] != uint64(3) {
+		t.Errorf("invalid query span %v", query.tags)
+	}
+	if tr.spans[1].tags[humus.TagMutations] != 1 {
+		t.Errorf("invalid mutate span %v", tr.spans[1].tags)
+	}
+}
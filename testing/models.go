@@ -22,7 +22,7 @@ type Post struct {
 	DatePublished *time.Time `json:"datePublished" predicate:"Post.datePublished,omitempty"`
 }
 
-var PostFields humus.Fields = humus.FieldList([]humus.Field{MakeField("Post.text", 0), MakeField("Post.datePublished", 0)})
+var PostFields humus.Fields = humus.FieldList([]humus.Field{MakeField("Post.text", 0|humus.MetaIndexHash), MakeField("Post.datePublished", 0)})
 
 //Generating constant field values.
 const (
@@ -128,7 +128,7 @@ type Question struct {
 	Comments []*Comment `json:"comments" predicate:"Question.comments,omitempty"`
 }
 
-var QuestionFields humus.Fields = humus.FieldList([]humus.Field{MakeField("Question.title", 0), MakeField("Question.from", 0|humus.MetaObject), MakeField("Question.comments", 0|humus.MetaObject|humus.MetaList), MakeField("Post.text", 0), MakeField("Post.datePublished", 0)})
+var QuestionFields humus.Fields = humus.FieldList([]humus.Field{MakeField("Question.title", 0|humus.MetaIndexHash), MakeField("Question.from", 0|humus.MetaObject), MakeField("Question.comments", 0|humus.MetaObject|humus.MetaList), MakeField("Post.text", 0|humus.MetaIndexHash), MakeField("Post.datePublished", 0)})
 
 //Generating constant field values.
 const (
@@ -244,7 +244,7 @@ type Comment struct {
 	From *User `json:"from" predicate:"Comment.from,omitempty"`
 }
 
-var CommentFields humus.Fields = humus.FieldList([]humus.Field{MakeField("Comment.from", 0|humus.MetaObject), MakeField("Post.text", 0), MakeField("Post.datePublished", 0)})
+var CommentFields humus.Fields = humus.FieldList([]humus.Field{MakeField("Comment.from", 0|humus.MetaObject), MakeField("Post.text", 0|humus.MetaIndexHash), MakeField("Post.datePublished", 0)})
 
 //Generating constant field values.
 const (
@@ -354,7 +354,7 @@ type User struct {
 	Email string `json:"email" predicate:"User.email,omitempty"`
 }
 
-var UserFields humus.Fields = humus.FieldList([]humus.Field{MakeField("User.name", 0), MakeField("User.email", 0)})
+var UserFields humus.Fields = humus.FieldList([]humus.Field{MakeField("User.name", 0|humus.MetaIndexHash), MakeField("User.email", 0)})
 
 //Generating constant field values.
 const (
@@ -0,0 +1,49 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+const mergedFilterQuery = "query t($0:string,$1:string,$2:string,$3:string){q0(func: type($3))" +
+	"@filter(eq(<Question.title>,$0) AND (eq(<Post.text>,$1) OR eq(<Post.text>,$2))){Question.title " +
+	"Question.comments@filter(has(<Post.text>) AND has(<Comment.from>)){ uid} Post.text Post.datePublished  uid}}"
+
+func TestMergeFilters(t *testing.T) {
+	var q = humus.NewQuery(QuestionFields.Sub(QuestionCommentsField, humus.NewList{})).Function(humus.Type).Values("Question")
+	q.At("", func(m humus.Mod) {
+		m.Filter(humus.Equals, QuestionTitleField, "First Question")
+	})
+	q.At("", func(m humus.Mod) {
+		m.FilterTree(humus.Or(
+			humus.NewFilter(humus.Equals, PostTextField, "a"),
+			humus.NewFilter(humus.Equals, PostTextField, "b"),
+		))
+	})
+	q.At(QuestionCommentsField, func(m humus.Mod) {
+		m.Filter(humus.Has, PostTextField)
+		m.Filter(humus.Has, CommentFromField)
+	})
+	str, err := q.Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != mergedFilterQuery {
+		t.Errorf("invalid merged filter query, got %s", str)
+	}
+}
+
+func TestModifierConflict(t *testing.T) {
+	var q = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question")
+	q.At("", func(m humus.Mod) {
+		m.Paginate(humus.CountFirst, 10)
+	})
+	q.At("", func(m humus.Mod) {
+		m.Paginate(humus.CountFirst, 20)
+	})
+	if _, err := q.Process(); err == nil {
+		t.Error("expected error on pagination set twice")
+	}
+}
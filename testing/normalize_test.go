@@ -0,0 +1,37 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+type questionRow struct {
+	Title  string `predicate:"Question.title"`
+	Author string `predicate:"User.name"`
+	Text   string `predicate:"Post.text"`
+}
+
+func TestNormalize(t *testing.T) {
+	fields := QuestionFields.Select(QuestionTitleField, QuestionFromField, QuestionCommentsField).
+		Sub(QuestionFromField, UserFields).
+		Sub(QuestionCommentsField, CommentFields.Select(CommentTextField))
+	str, err := humus.NewQuery(fields).Function(humus.Type).Values("Question").Normalize([]questionRow{}).Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	const exp = "query t($0:string){q0(func: type($0))@normalize{Question.title : Question.title " +
+		"Question.from{User.name : User.name  User.email  uid} Question.comments{Post.text : Post.text  uid}  uid}}"
+	if str != exp {
+		t.Errorf("invalid normalize query, got %s", str)
+	}
+	_, err = humus.NewQuery(UserFields).Function(humus.Type).Values("User").Normalize(questionRow{}).Process()
+	if err == nil {
+		t.Error("expected error on normalize predicate not in query")
+	}
+	_, err = humus.NewQuery(UserFields).Function(humus.Type).Values("User").Normalize("").Process()
+	if err == nil {
+		t.Error("expected error on normalize without struct")
+	}
+}
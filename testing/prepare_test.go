@@ -0,0 +1,52 @@
+package gen
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+func TestPrepare(t *testing.T) {
+	q := humus.NewQuery(UserFields).Function(humus.FunctionUid).Values(humus.UID("0x1"))
+	q.At("", func(m humus.Mod) {
+		m.Filter(humus.Equals, UserNameField, "User")
+	})
+	p, err := q.Prepare()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if p.Params() != 2 {
+		t.Errorf("invalid amount of params %d", p.Params())
+	}
+	const exp = "query t($0:string,$1:string){q0(func: uid($1))@filter(eq(<User.name>,$0)){User.name User.email  uid}}"
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			str, err := p.Bind(humus.UID("0x2"), "Simon").Process()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if str != exp {
+				t.Errorf("invalid prepared query, got %s", str)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := p.Bind(humus.UID("0x2")).Process(); err == nil {
+		t.Error("expected error on missing value")
+	}
+	if _, err := p.Bind("0x2", "Simon").Process(); err == nil {
+		t.Error("expected error on invalid type")
+	}
+	if _, err := p.Bind(humus.UID("0xz"), "Simon").Process(); err == nil {
+		t.Error("expected error on invalid uid")
+	}
+	if _, err := q.Prepare(); err == nil {
+		t.Error("expected error on prepare of processed query")
+	}
+}
@@ -0,0 +1,127 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+func TestQueryName(t *testing.T) {
+	str, err := humus.NewQuery(UserFields).Function(humus.Type).Values("User").Name("users").Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != "query t($0:string){users(func: type($0)){User.name User.email  uid}}" {
+		t.Errorf("invalid named query, got %s", str)
+	}
+	_, err = humus.NewQuery(UserFields).Function(humus.Type).Values("User").Name("users{").Process()
+	if err == nil {
+		t.Error("expected error on invalid name")
+	}
+	var qs = humus.NewQueries()
+	qs.NewQuery(UserFields).Function(humus.Type).Values("User").Name("users")
+	qs.NewQuery(UserFields).Function(humus.Type).Values("User").Name("users")
+	if _, err = qs.Process(); err == nil {
+		t.Error("expected error on duplicate name")
+	}
+	//The second block is generated as q1.
+	qs = humus.NewQueries()
+	qs.NewQuery(UserFields).Function(humus.Type).Values("User").Name("q1")
+	qs.NewQuery(UserFields).Function(humus.Type).Values("User")
+	if _, err = qs.Process(); err == nil {
+		t.Error("expected error on reserved name")
+	}
+}
+
+func TestQueriesHeader(t *testing.T) {
+	var cases = []struct {
+		name  string
+		build func(q *humus.Queries)
+		exp   string
+	}{
+		{"empty", func(q *humus.Queries) {}, "query{}"},
+		{"single static", func(q *humus.Queries) {
+			q.NewQuery(UserFields).Function(humus.Has).Values(UserNameField)
+		}, "query{q0(func: has(<User.name>)){User.name User.email  uid}}"},
+		{"single vars", func(q *humus.Queries) {
+			q.NewQuery(UserFields).Function(humus.Type).Values("User")
+		}, "query t($0:string){q0(func: type($0)){User.name User.email  uid}}"},
+		{"vars then static", func(q *humus.Queries) {
+			q.NewQuery(UserFields).Function(humus.Type).Values("User")
+			q.NewQuery(UserFields).Function(humus.Has).Values(UserNameField)
+		}, "query t($0:string){q0(func: type($0)){User.name User.email  uid}q1(func: has(<User.name>)){User.name User.email  uid}}"},
+		{"static then vars", func(q *humus.Queries) {
+			q.NewQuery(UserFields).Function(humus.Has).Values(UserNameField)
+			q.NewQuery(UserFields).Function(humus.Type).Values("User")
+		}, "query t($0:string){q0(func: has(<User.name>)){User.name User.email  uid}q1(func: type($0)){User.name User.email  uid}}"},
+		{"vars in both", func(q *humus.Queries) {
+			q.NewQuery(UserFields).Function(humus.Type).Values("User")
+			q.NewQuery(UserFields).Function(humus.Equals).Values(UserNameField, "Simon")
+		}, "query t($0:string,$1:string){q0(func: type($0)){User.name User.email  uid}q1(func: eq(<User.name>,$1)){User.name User.email  uid}}"},
+		{"static between vars", func(q *humus.Queries) {
+			q.NewQuery(UserFields).Function(humus.Type).Values("User")
+			q.NewQuery(UserFields).Function(humus.Has).Values(UserNameField)
+			q.NewQuery(UserFields).Function(humus.Equals).Values(UserNameField, "Simon")
+		}, "query t($0:string,$1:string){q0(func: type($0)){User.name User.email  uid}q1(func: has(<User.name>)){User.name User.email  uid}q2(func: eq(<User.name>,$1)){User.name User.email  uid}}"},
+		{"var only", func(q *humus.Queries) {
+			q.NewQuery(UserFields).Function(humus.Type).Values("User").Var("users")
+		}, "query t($0:string){users as var(func: type($0)){User.name User.email  uid}}"},
+		{"var block then query", func(q *humus.Queries) {
+			q.NewQuery(UserFields).Function(humus.Has).Values(UserNameField).Var("users")
+			q.NewQuery(UserFields).Function(humus.FunctionUid).Values(humus.Variable("users"))
+		}, "query{users as var(func: has(<User.name>)){User.name User.email  uid}q0(func: uid(users)){User.name User.email  uid}}"},
+		{"static block only", func(q *humus.Queries) {
+			q.Static("", "users as var(func: has(User.name))")
+		}, "query{users as var(func: has(User.name))}"},
+		{"static block vars", func(q *humus.Queries) {
+			q.Static("users", "users(func: eq(User.name, $name)){uid}").Var("$name", "string", "Simon")
+		}, "query t($name:string){users(func: eq(User.name, $name)){uid}}"},
+		{"static block and generated", func(q *humus.Queries) {
+			q.Static("", "users as var(func: eq(User.name, $name))").Var("$name", "string", "Simon")
+			q.NewQuery(UserFields).Function(humus.FunctionUid).Values(humus.Variable("users"))
+			q.NewQuery(UserFields).Function(humus.Type).Values("User")
+		}, "query t($name:string,$0:string){users as var(func: eq(User.name, $name))q0(func: uid(users)){User.name User.email  uid}q1(func: type($0)){User.name User.email  uid}}"},
+		{"static blocks sharing vars", func(q *humus.Queries) {
+			q.Static("users", "users(func: eq(User.name, $name)){uid}").Var("$name", "string", "Simon")
+			q.Static("names", "names(func: eq(User.name, $name)){User.name}").Var("$name", "string", "Simon")
+		}, "query t($name:string){users(func: eq(User.name, $name)){uid}names(func: eq(User.name, $name)){User.name}}"},
+	}
+	for _, c := range cases {
+		q := humus.NewQueries()
+		c.build(q)
+		str, err := q.Process()
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		if str != c.exp {
+			t.Errorf("%s: got %s", c.name, str)
+		}
+	}
+}
+
+func TestStaticBlockInvalid(t *testing.T) {
+	q := humus.NewQueries()
+	q.Static("users", "users(func: eq(User.name, $0)){uid}").Var("$0", "string", "Simon")
+	if _, err := q.Process(); err == nil {
+		t.Error("expected error on numeric static variable")
+	}
+	q = humus.NewQueries()
+	q.Static("users", "users(func: eq(User.name, $name)){uid}").Var("$name", "date", "Simon")
+	if _, err := q.Process(); err == nil {
+		t.Error("expected error on invalid static variable type")
+	}
+	q = humus.NewQueries()
+	q.Static("users", "users(func: eq(User.name, $name)){uid}").Var("$name", "string", "Simon")
+	q.Static("names", "names(func: eq(User.name, $name)){uid}").Var("$name", "string", "Other")
+	if _, err := q.Process(); err == nil {
+		t.Error("expected error on static variable with different values")
+	}
+	q = humus.NewQueries()
+	q.Static("users", "users(func: eq(User.name, $name)){uid}").Var("$name", "string", "Simon")
+	q.Static("names", "names(func: eq(User.name, $name)){uid}").Var("$name", "int", "Simon")
+	if _, err := q.Process(); err == nil {
+		t.Error("expected error on static variable with different types")
+	}
+}
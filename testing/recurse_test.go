@@ -0,0 +1,30 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+const recurseQuery = "query t($0:string){q0(func: type($0))@recurse(depth: 3, loop: false)" +
+	"{Question.title Question.from Question.comments@filter(has(<Post.text>)) Post.text Post.datePublished  uid}}"
+
+func TestRecurseQuery(t *testing.T) {
+	var q = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question").Recurse(3, false)
+	//The filter applies to the comments edge at every level.
+	q.At(QuestionCommentsField, func(m humus.Mod) {
+		m.Filter(humus.Has, PostTextField)
+	})
+	str, err := q.Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != recurseQuery {
+		t.Errorf("invalid recurse query, got %s", str)
+	}
+	_, err = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question").Recurse(0, true).Process()
+	if err == nil {
+		t.Error("expected error for loop without depth")
+	}
+}
@@ -0,0 +1,74 @@
+package gen
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+const shortestQuery = "query{path as shortest(from: 0x1, to: 0x5, numpaths: 2, depth: 4)" +
+	"{Question.from Question.comments @facets(weight)}q0(func: uid(path)){User.name User.email  uid}}"
+
+func TestShortestQuery(t *testing.T) {
+	q := humus.NewShortestQuery("0x1", "0x5", UserFields).
+		Edge(QuestionFromField).
+		WeightedEdge(QuestionCommentsField, "weight").
+		NumPaths(2).Depth(4)
+	str, err := q.Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != shortestQuery {
+		t.Errorf("invalid shortest query, got %s", str)
+	}
+	_, err = humus.NewShortestQuery("0x1", "user", UserFields).Edge(QuestionFromField).Process()
+	if err == nil {
+		t.Error("expected error on invalid uid")
+	}
+}
+
+const pathResult = `[{"uid":"0x1","Question.from":{"uid":"0x3","Question.comments":[{"uid":"0x5"}]},"_weight_":2}]`
+
+func TestPath(t *testing.T) {
+	var paths []humus.Path
+	if err := json.Unmarshal([]byte(pathResult), &paths); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(paths) != 1 || len(paths[0].Uids) != 3 || paths[0].Uids[2] != "0x5" || paths[0].Weight != 2 {
+		t.Errorf("invalid path %v", paths)
+		return
+	}
+	var users []*User
+	for _, v := range []humus.UID{"0x5", "0x1", "0x3"} {
+		var u User
+		u.SetUID(v)
+		users = append(users, &u)
+	}
+	if err := paths[0].Sort(users); err != nil {
+		t.Error(err)
+		return
+	}
+	if users[0].Uid != "0x1" || users[1].Uid != "0x3" || users[2].Uid != "0x5" {
+		t.Fail()
+	}
+}
+
+//The path from 0x1 has two candidate edges, the first in the response is followed.
+const orderedPathResult = `{"uid":"0x1","Question.comments":[{"uid":"0x4"}],"Question.from":{"uid":"0x3"}}`
+
+func TestPathOrder(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		var p humus.Path
+		if err := json.Unmarshal([]byte(orderedPathResult), &p); err != nil {
+			t.Error(err)
+			return
+		}
+		if len(p.Uids) != 2 || p.Uids[1] != "0x4" {
+			t.Errorf("invalid path order %v", p.Uids)
+			return
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+func TestValidate(t *testing.T) {
+	var sch = humus.SchemaList(GetGlobalFields())
+	var cases = []struct {
+		name  string
+		query *humus.GeneratedQuery
+		valid bool
+	}{
+		{"valid", humus.NewQuery(QuestionFields.Sub(QuestionFromField, UserFields)).
+			Function(humus.Equals).Values(QuestionTitleField, "First Question"), true},
+		{"unknown predicate", humus.NewQuery(UserFields.Add(humus.MakeField("User.age", 0))).
+			Function(humus.Type).Values("User"), false},
+		{"sub on scalar", humus.NewQuery(UserFields.Sub(UserNameField, UserFields)).
+			Function(humus.Type).Values("User"), false},
+		{"sort on list", humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question").
+			At("", func(m humus.Mod) {
+				m.Sort(humus.Ascending, QuestionCommentsField)
+			}), false},
+		{"eq without index", humus.NewQuery(UserFields).Function(humus.Equals).Values(UserEmailField, "a@b.c"), false},
+		{"regexp without trigram", humus.NewQuery(UserFields).Function(humus.Type).Values("User").
+			At("", func(m humus.Mod) {
+				m.Filter(humus.Regexp, UserNameField, "/^U.*$/")
+			}), false},
+	}
+	for _, v := range cases {
+		_, err := v.query.Validate(sch).Process()
+		if (err == nil) != v.valid {
+			t.Errorf("%s: expected valid %v, got %v", v.name, v.valid, err)
+		}
+	}
+	qs := humus.NewQueries().Validate(sch)
+	qs.NewQuery(UserFields).Function(humus.Equals).Values(UserNameField, "User")
+	qs.NewQuery(UserFields).Function(humus.AllOfTerms).Values(UserNameField, "User")
+	if _, err := qs.Process(); err == nil {
+		t.Error("expected error on allofterms without index")
+	}
+}
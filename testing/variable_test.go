@@ -0,0 +1,124 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/Vliro/humus"
+)
+
+const mathQuery = "query t($1:string,$0:int){var(func: type($1)){ c as count(Question.comments)  score as math(c * 2 + 1)  uid}" +
+	"q0(func: uid(score),orderdesc: val(score))@filter(gt(val(score),$0)){Question.title Post.text Post.datePublished  score : val(score)  uid}}"
+
+func TestMathVariable(t *testing.T) {
+	//The score is declared in a var block and used at the root of the next block.
+	var qs = humus.NewQueries()
+	qs.NewQuery(humus.NewList{}).Function(humus.Type).Values("Question").Var("").At("", func(m humus.Mod) {
+		m.Value("c", "count(Question.comments)")
+		m.Math("score", "c * 2 + 1")
+	})
+	qs.NewQuery(QuestionFields).Function(humus.FunctionUid).Values(humus.Variable("score")).At("", func(m humus.Mod) {
+		m.SortVariable(humus.Descending, "score")
+		m.Filter(humus.Greater, humus.Variable("score"), 1)
+		m.Aggregate(humus.Val, "score", "score")
+	})
+	str, err := qs.Process()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str != mathQuery {
+		t.Errorf("invalid math query, got %s", str)
+	}
+	//Dgraph rejects variables used at the root of the block declaring them.
+	var q = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question")
+	q.At("", func(m humus.Mod) {
+		m.Value("c", "count(Question.comments)")
+		m.Math("score", "c * 2 + 1")
+		m.SortVariable(humus.Descending, "score")
+	})
+	if _, err := q.Process(); err == nil {
+		t.Error("expected error on variable sorted in the declaring block")
+	}
+	q = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question")
+	q.At("", func(m humus.Mod) {
+		m.Value("c", "count(Question.comments)")
+		m.Filter(humus.Greater, humus.Variable("c"), 1)
+	})
+	if _, err := q.Process(); err == nil {
+		t.Error("expected error on variable filtered in the declaring block")
+	}
+	q = humus.NewQuery(QuestionFields).Function(humus.FunctionUid).Values(humus.Variable("c")).At("", func(m humus.Mod) {
+		m.Value("c", "count(Question.comments)")
+	})
+	if _, err := q.Process(); err == nil {
+		t.Error("expected error on variable in the root function of the declaring block")
+	}
+}
+
+func TestUndeclaredVariable(t *testing.T) {
+	var q = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question")
+	q.At("", func(m humus.Mod) {
+		m.Math("score", "c * 2")
+	})
+	if _, err := q.Process(); err == nil {
+		t.Error("expected error on undeclared variable")
+	}
+	var invalid bool
+	q.At("", func(m humus.Mod) {
+		invalid = !m.Math("score", "c; drop")
+	})
+	if !invalid {
+		t.Error("expected invalid math expression")
+	}
+	//The variable is used in the first query but declared in the second.
+	var qs = humus.NewQueries()
+	qs.NewQuery(QuestionFields).Function(humus.FunctionUid).Values(humus.Variable("questions"))
+	qs.NewQuery(QuestionFields).Function(humus.Type).Values("Question").Var("questions")
+	if _, err := qs.Process(); err == nil {
+		t.Error("expected error on variable used before declaration")
+	}
+}
+
+func TestInvalidVariable(t *testing.T) {
+	var tests = []struct {
+		name string
+		op   humus.Operation
+	}{
+		{"filter", func(m humus.Mod) { m.Filter(humus.Greater, humus.Variable("score)"), 1) }},
+		{"filter tree", func(m humus.Mod) { m.FilterTree(humus.NewFilter(humus.Greater, humus.Variable("a b"), 1)) }},
+		{"value", func(m humus.Mod) { m.Value("c)", "count(Question.comments)") }},
+		{"math name", func(m humus.Mod) { m.Math("score)", "1 + 2") }},
+		{"math expression", func(m humus.Mod) { m.Math("score", "c; drop") }},
+		{"sort", func(m humus.Mod) { m.SortVariable(humus.Descending, "score)") }},
+	}
+	for _, v := range tests {
+		q := humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question")
+		q.At("", v.op)
+		if _, err := q.Process(); err == nil {
+			t.Errorf("%s: expected error on invalid variable", v.name)
+		}
+	}
+	q := humus.NewQuery(QuestionFields.Sub(QuestionCommentsField, CommentFields)).Function(humus.Type).Values("Question")
+	q.GroupBy(QuestionCommentsField, CommentFromField, func(m humus.Mod) {
+		m.Value("c)", "count(uid)")
+	})
+	if _, err := q.Process(); err == nil {
+		t.Error("expected error on invalid group variable")
+	}
+	q = humus.NewQuery(QuestionFields).Function(humus.Type).Values("Question")
+	q.Facets(QuestionCommentsField, func(m humus.Mod) {
+		m.Value("c)", "weight")
+	})
+	if _, err := q.Process(); err == nil {
+		t.Error("expected error on invalid facet variable")
+	}
+	//Value variables in the root function are checked as well.
+	if _, err := humus.NewQuery(QuestionFields).Function(humus.FunctionUid).Values(humus.Variable("a)")).Process(); err == nil {
+		t.Error("expected error on invalid root variable")
+	}
+	var qs = humus.NewQueries()
+	qs.NewQuery(QuestionFields).Function(humus.FunctionUid).Values(humus.Variable("a)"))
+	if _, err := qs.Process(); err == nil {
+		t.Error("expected error on invalid root variable in queries")
+	}
+}
@@ -0,0 +1,100 @@
+package humus
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/dgraph-io/dgo/protos/api"
+)
+
+//The names of the spans of the transaction operations.
+const (
+	SpanQuery   = "humus.query"
+	SpanMutate  = "humus.mutate"
+	SpanUpsert  = "humus.upsert"
+	SpanCommit  = "humus.commit"
+	SpanDiscard = "humus.discard"
+)
+
+//The tags set on the spans.
+const (
+	TagNames        = "humus.names"
+	TagMutations    = "humus.mutations"
+	TagStartTs      = "dgraph.start_ts"
+	TagParsingNs    = "dgraph.parsing_ns"
+	TagProcessingNs = "dgraph.processing_ns"
+	TagEncodingNs   = "dgraph.encoding_ns"
+)
+
+//Span is a single traced operation.
+type Span interface {
+	//SetTag annotates the span.
+	SetTag(key string, value interface{})
+	//Finish ends the span along with the error of the operation, if any.
+	Finish(err error)
+}
+
+//Tracer opens a span for every query, mutation, upsert, commit and discard in a
+//transaction, named by the Span constants. The returned context is used for the
+//operation, which allows the span to be propagated i.e. to grpc interceptors.
+//Implement it to use the tracer of the application, i.e. OpenTracing.
+type Tracer interface {
+	StartSpan(ctx context.Context, operation string) (context.Context, Span)
+}
+
+//NoopTracer does not trace.
+type NoopTracer struct{}
+
+func (NoopTracer) StartSpan(ctx context.Context, operation string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetTag(key string, value interface{}) {}
+
+func (noopSpan) Finish(err error) {}
+
+//txnSpan is the span of an operation in a transaction.
+type txnSpan struct {
+	span Span
+	txn  *Txn
+}
+
+//startSpan opens a span for the operation, tagged with the start timestamp if known.
+func (t *Txn) startSpan(ctx context.Context, operation string) (context.Context, txnSpan) {
+	var tracer Tracer = NoopTracer{}
+	if t.db.tracer != nil {
+		tracer = t.db.tracer
+	}
+	ctx, span := tracer.StartSpan(ctx, operation)
+	if ts := atomic.LoadUint64(&t.startTs); ts != 0 {
+		span.SetTag(TagStartTs, ts)
+	}
+	return ctx, txnSpan{span: span, txn: t}
+}
+
+//request tags the block names and amount of mutations of the request.
+func (s txnSpan) request(req *Request) {
+	if len(req.Names) > 0 {
+		s.span.SetTag(TagNames, strings.Join(req.Names, ","))
+	}
+	s.span.SetTag(TagMutations, len(req.Mutations))
+}
+
+//finish tags the start timestamp and server latency of the response, if any, and ends the span.
+func (s txnSpan) finish(resp *api.Response, err error) {
+	if resp != nil {
+		if resp.Txn != nil && resp.Txn.StartTs != 0 {
+			atomic.StoreUint64(&s.txn.startTs, resp.Txn.StartTs)
+			s.span.SetTag(TagStartTs, resp.Txn.StartTs)
+		}
+		if l := resp.Latency; l != nil {
+			s.span.SetTag(TagParsingNs, l.ParsingNs)
+			s.span.SetTag(TagProcessingNs, l.ProcessingNs)
+			s.span.SetTag(TagEncodingNs, l.EncodingNs)
+		}
+	}
+	s.span.Finish(err)
+}
@@ -0,0 +1,145 @@
+package humus
+
+import (
+	"fmt"
+	"strings"
+)
+
+//indexRequirements are the indexes of which one is required
+//on the predicate for a function.
+var indexRequirements = map[FunctionType]FieldMeta{
+	Equals:     MetaIndexHash | MetaIndexExact | MetaIndexTerm | MetaIndex,
+	AllOfTerms: MetaIndexTerm,
+	AnyOfTerms: MetaIndexTerm,
+	AllOfText:  MetaIndexFulltext,
+	AnyOfText:  MetaIndexFulltext,
+	Regexp:     MetaIndexTrigram,
+}
+
+//Validate sets the schema to validate the query against when it is processed.
+//Unknown predicates, Sub on a non-object field, Sort on a list predicate and
+//filters on predicates without the required index are rejected.
+func (q *GeneratedQuery) Validate(sch SchemaList) *GeneratedQuery {
+	q.schema = sch
+	return q
+}
+
+//Validate sets the schema to validate all queries against when processed.
+//See GeneratedQuery.Validate.
+func (q *Queries) Validate(sch SchemaList) *Queries {
+	q.schema = sch
+	return q
+}
+
+//builtinPredicates are predicates that exist without being in the schema.
+var builtinPredicates = map[Predicate]struct{}{
+	"uid":         {},
+	"dgraph.type": {},
+}
+
+//lookup returns the schema field for the predicate. Reverse predicates
+//are looked up using the forward predicate.
+func (s SchemaList) lookup(p Predicate) (Field, error) {
+	if _, ok := builtinPredicates[p]; ok {
+		return Field{Name: p}, nil
+	}
+	f, ok := s[Predicate(strings.TrimPrefix(string(p), "~"))]
+	if !ok {
+		return f, fmt.Errorf("unknown predicate %s", p)
+	}
+	return f, nil
+}
+
+//validate validates the query against the schema.
+func (q *GeneratedQuery) validate(sch SchemaList) error {
+	if err := sch.validateFunction(&q.function); err != nil {
+		return err
+	}
+	if err := sch.validateFields(q.fields); err != nil {
+		return err
+	}
+	for _, v := range q.modifiers {
+		for _, mod := range v.m {
+			switch a := mod.(type) {
+			case *Filter:
+				if err := sch.validateFilter(a); err != nil {
+					return err
+				}
+			case Ordering:
+				//Sorting on value variables.
+				if strings.IndexByte(string(a.Predicate), '(') != -1 {
+					continue
+				}
+				f, err := sch.lookup(a.Predicate)
+				if err != nil {
+					return err
+				}
+				if f.Meta.List() {
+					return fmt.Errorf("sort on list predicate %s", a.Predicate)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s SchemaList) validateFields(fields Fields) error {
+	if fields == nil {
+		return nil
+	}
+	for _, v := range fields.Get() {
+		if v.Name == "" || v.Meta.Empty() || v.Meta.Facet() {
+			continue
+		}
+		//The predicates of an expand field are not known.
+		if v.Meta.Expand() {
+			continue
+		}
+		f, err := s.lookup(v.Name)
+		if err != nil {
+			return err
+		}
+		if v.Fields == nil || v.Fields.Len() == 0 {
+			continue
+		}
+		if !f.Meta.Object() && !f.Meta.Reverse() && v.Name[0] != '~' {
+			return fmt.Errorf("sub on non-object predicate %s", v.Name)
+		}
+		if err := s.validateFields(v.Fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s SchemaList) validateFilter(f *Filter) error {
+	if f.leaf() {
+		return s.validateFunction(&f.function)
+	}
+	for _, v := range f.nodes {
+		if err := s.validateFilter(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//validateFunction ensures the predicate of the function exists along with the index it requires.
+func (s SchemaList) validateFunction(f *function) error {
+	if len(f.variables) == 0 || f.variables[0].Type != typePred {
+		return nil
+	}
+	pred := Predicate(f.variables[0].Value)
+	field, err := s.lookup(pred)
+	if err != nil {
+		return err
+	}
+	//Functions with a subfunction, i.e. lt(count(pred)..., do not use the index.
+	if strings.IndexByte(string(f.typ), '(') != -1 {
+		return nil
+	}
+	if index, ok := indexRequirements[f.typ]; ok && !field.Meta.Indexed(index) {
+		return fmt.Errorf("function %s on predicate %s without the required index", f.typ, pred)
+	}
+	return nil
+}
@@ -8,12 +8,172 @@ import (
 )
 
 /*
-Variable represents a value variable. This static type enforces a
-'val' in the query generation in e.g. functions and filters.
-//TODO, not implemented yet.
+Variable represents a query variable. This static type enforces a
+'val' in the query generation in e.g. functions and filters, i.e. gt(val(x), $0),
+while it is written as is in the uid function, i.e. uid(x).
+Declare them using Mod.Value, Mod.Math, Mod.Variable or GeneratedQuery.Var.
 */
 type Variable string
 
+//declare marks the variable as declared in this query.
+func (q *GeneratedQuery) declare(v Variable) {
+	for _, val := range q.declared {
+		if val == v {
+			return
+		}
+	}
+	q.declared = append(q.declared, v)
+}
+
+//use marks the variable as used in this query.
+func (q *GeneratedQuery) use(v Variable) {
+	for _, val := range q.used {
+		if val == v {
+			return
+		}
+	}
+	q.used = append(q.used, v)
+}
+
+//checkVariables ensures all variables used in this query are declared, either
+//in this query or in declared, which contains variables from previous queries.
+func (q *GeneratedQuery) checkVariables(declared map[Variable]struct{}) error {
+loop:
+	for _, v := range q.used {
+		if _, ok := declared[v]; ok {
+			continue
+		}
+		for _, val := range q.declared {
+			if val == v {
+				continue loop
+			}
+		}
+		return fmt.Errorf("variable %s used before it is declared", v)
+	}
+	//Variables in the root function, filter and sort must come from another block.
+	//The path of a shortest query is declared in the shortest block.
+	for _, v := range q.rootUses() {
+		if q.shortest != nil && v == Variable(q.shortest.varName) {
+			continue
+		}
+		for _, val := range q.declared {
+			if val == v {
+				return fmt.Errorf("variable %s used at the root of the block declaring it", v)
+			}
+		}
+	}
+	return nil
+}
+
+//rootUses returns the variables used in the root function, filter and sort of the query.
+func (q *GeneratedQuery) rootUses() []Variable {
+	ret := q.function.uses(nil)
+	root, ok := q.modifiers[""]
+	if !ok {
+		return ret
+	}
+	for _, v := range root.m {
+		switch a := v.(type) {
+		case *Filter:
+			ret = a.uses(ret)
+		case Ordering:
+			p := string(a.Predicate)
+			if strings.HasPrefix(p, "val(") && strings.HasSuffix(p, ")") {
+				ret = append(ret, Variable(p[len("val("):len(p)-1]))
+			}
+		}
+	}
+	return ret
+}
+
+//uses appends the variables used in the function to ret.
+func (f *function) uses(ret []Variable) []Variable {
+	for _, v := range f.variables {
+		if v.Type == typeVar {
+			ret = append(ret, Variable(v.Value))
+		}
+	}
+	return ret
+}
+
+//uses appends the variables used in the filter tree to ret.
+func (f *Filter) uses(ret []Variable) []Variable {
+	if f == nil {
+		return ret
+	}
+	if f.leaf() {
+		return f.function.uses(ret)
+	}
+	for _, v := range f.nodes {
+		ret = v.uses(ret)
+	}
+	return ret
+}
+
+//validVariableName returns whether name is a valid query variable name
+//and as such safe to be written into a query.
+func validVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for k, v := range name {
+		switch {
+		case v >= 'a' && v <= 'z', v >= 'A' && v <= 'Z', v == '_':
+		case v >= '0' && v <= '9' && k > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+//Geo represents a geo value, written as GeoJSON coordinates into geo functions such as
+//near and within. It is only built from numbers using Point and Polygon as it is
+//written into the query as is.
+type Geo struct {
+	coordinates string
+}
+
+//Point creates a geo point from its longitude and latitude.
+func Point(long, lat float64) Geo {
+	return Geo{"[" + formatCoordinate(long) + "," + formatCoordinate(lat) + "]"}
+}
+
+//Polygon creates a geo polygon from a list of [longitude, latitude] points.
+//The first and last point should be the same to close the polygon.
+func Polygon(points ...[2]float64) Geo {
+	var sb strings.Builder
+	sb.WriteString("[[")
+	for k, v := range points {
+		if k != 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(Point(v[0], v[1]).coordinates)
+	}
+	sb.WriteString("]]")
+	return Geo{sb.String()}
+}
+
+func formatCoordinate(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+//Vector represents a float32 vector used in similar_to.
+type Vector []float32
+
+func (v Vector) String() string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for k, val := range v {
+		if k != 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(val), 'f', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
 //processInterface takes the type and returns what variable it is as well as a string representation of it.
 //this function is the reason why using the default generated values is important since it includes the static type
 //of predicate/uid.,
@@ -36,6 +196,10 @@ func processInterface(value interface{}) (string, varType) {
 		return strconv.FormatFloat(a, 'f', 16, 64), typeFloat
 	case Variable:
 		return string(a), typeVar
+	case Geo:
+		return a.coordinates, typeGeo
+	case Vector:
+		return a.String(), typeVector
 	default:
 		return fmt.Sprintf("%s", a), typeString
 	}